@@ -5,7 +5,6 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/TimothyYe/godns/internal/manager"
 	"github.com/TimothyYe/godns/internal/settings"
@@ -26,6 +25,8 @@ var (
 	optAddr = flag.String("a", ":9000", "Specify the address to listen on")
 	optConf = flag.String("c", "./config.json", "Specify a config file")
 	optHelp = flag.Bool("h", false, "Show help")
+	// optDryRun overrides the dry_run setting, including after a configuration reload
+	optDryRun = flag.Bool("dry-run", false, "Show the planned DNS changes without applying them")
 
 	// Version is current version of GoDNS.
 	Version = "v0.1"
@@ -63,18 +64,28 @@ func main() {
 	// set the log level
 	log.SetOutput(os.Stdout)
 
+	if *optDryRun {
+		config.DryRun = true
+	}
+
 	if config.DebugInfo {
 		log.SetLevel(log.DebugLevel)
 	} else {
 		log.SetLevel(log.InfoLevel)
 	}
 
+	// split the hostnames by zone before validating the domains
+	if err := utils.ExpandHostnames(&config, utils.NewZoneLookup(config.Resolver)); err != nil {
+		log.Fatal("Invalid settings: ", err.Error())
+	}
+
 	if err := utils.CheckSettings(&config); err != nil {
 		log.Fatal("Invalid settings: ", err.Error())
 	}
 
 	// Create DNS manager
 	dnsManager := manager.GetDNSManager(configPath, &config, *optAddr)
+	dnsManager.SetDryRun(*optDryRun)
 
 	// Run DNS manager
 	log.Info("GoDNS started, starting the DNS manager...")
@@ -83,6 +94,7 @@ func main() {
 	// handle the signals
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	handleControlSignals(dnsManager)
 
 	// stop the DNS manager
 	<-c
@@ -92,7 +104,5 @@ func main() {
 	// rather than in DNSManager.Stop() because Restart() also calls Stop()
 	// and we want the helper alive across restarts — it's a singleton.
 	lib.GetIPHelperInstance(&config).Stop()
-	// wait for the goroutines to exit
-	time.Sleep(200 * time.Millisecond)
 	log.Info("GoDNS is stopped, bye!")
 }
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/TimothyYe/godns/internal/manager"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleControlSignals reloads the configuration on SIGHUP and updates all
+// the records right away on SIGUSR1.
+func handleControlSignals(dnsManager *manager.DNSManager) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP, syscall.SIGUSR1)
+
+	go func() {
+		for sig := range c {
+			switch sig {
+			case syscall.SIGHUP:
+				log.Info("Received SIGHUP, reloading the configuration...")
+				if err := dnsManager.ReloadConfig(); err != nil {
+					log.Error(err)
+				}
+			case syscall.SIGUSR1:
+				log.Info("Received SIGUSR1, updating all the records...")
+				dnsManager.ForceUpdate()
+			}
+		}
+	}()
+}
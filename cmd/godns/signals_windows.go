@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "github.com/TimothyYe/godns/internal/manager"
+
+// handleControlSignals does nothing, SIGHUP and SIGUSR1 don't exist on Windows.
+func handleControlSignals(_ *manager.DNSManager) {}
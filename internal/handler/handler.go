@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/state"
 	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/TimothyYe/godns/pkg/lib"
 	"github.com/TimothyYe/godns/pkg/notification"
@@ -20,22 +24,45 @@ import (
 var (
 	errEmptyResult = errors.New("empty result")
 	errEmptyDomain = errors.New("NXDOMAIN")
+	// errNoRecord is returned by lastValue when the provider has no such record, which the update creates
+	errNoRecord = errors.New("no record at the provider")
 )
 
+// RecordError is the failure of the update of a single record.
+type RecordError struct {
+	Hostname string
+	Err      error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %s", settings.ToUnicode(e.Hostname), e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
 type Handler struct {
 	ctx                 context.Context
+	callCtx             context.Context
 	Configuration       *settings.Settings
-	dnsProvider         provider.IDNSProvider            // Legacy single provider
-	dnsProviders        map[string]provider.IDNSProvider // Multi-provider support
+	dnsProvider         provider.IDNSProviderV2            // Legacy single provider
+	dnsProviders        map[string]provider.IDNSProviderV2 // Multi-provider support
 	notificationManager notification.INotificationManager
 	ipManager           *lib.IPHelper
-	cachedIP            string
+	stateStore          *state.Store
 }
 
 func (handler *Handler) SetContext(ctx context.Context) {
 	handler.ctx = ctx
 }
 
+// SetCallContext sets the context of the provider calls. Unlike the handler
+// context, which stops starting new updates, it interrupts the calls in progress.
+func (handler *Handler) SetCallContext(ctx context.Context) {
+	handler.callCtx = ctx
+}
+
 func (handler *Handler) SetConfiguration(conf *settings.Settings) {
 	handler.Configuration = conf
 	handler.notificationManager = notification.GetNotificationManager(handler.Configuration)
@@ -46,69 +73,60 @@ func (handler *Handler) Init() {
 	handler.ipManager.UpdateConfiguration(handler.Configuration)
 }
 
-func (handler *Handler) SetProvider(provider provider.IDNSProvider) {
+// SetStateStore sets the store keeping the per-record update state.
+func (handler *Handler) SetStateStore(store *state.Store) {
+	handler.stateStore = store
+}
+
+func (handler *Handler) SetProvider(provider provider.IDNSProviderV2) {
 	handler.dnsProvider = provider
 }
 
-func (handler *Handler) SetProviders(providers map[string]provider.IDNSProvider) {
+func (handler *Handler) SetProviders(providers map[string]provider.IDNSProviderV2) {
 	handler.dnsProviders = providers
 }
 
-func (handler *Handler) LoopUpdateIP(ctx context.Context, domain *settings.Domain) error {
-	ticker := time.NewTicker(time.Second * time.Duration(handler.Configuration.Interval))
-	defer ticker.Stop()
-
-	// run once at the beginning
-	err := handler.UpdateIP(domain)
-	if err != nil {
-		log.WithError(err).Debug("Update IP failed during the DNS Update loop")
-	}
-	log.Debugf("DNS update loop finished, will run again in %d seconds", handler.Configuration.Interval)
-
-	for {
-		select {
-		case <-ticker.C:
-			err := handler.UpdateIP(domain)
-			if err != nil {
-				log.WithError(err).Debug("Update IP failed during the DNS Update loop")
-			}
-			log.Debugf("DNS update loop finished, will run again in %d seconds", handler.Configuration.Interval)
-		case <-ctx.Done():
-			log.Info("DNS update loop cancelled")
-			return nil
+func (handler *Handler) UpdateIP(domain *settings.Domain) error {
+	var errs []error
+	for _, ipType := range utils.GetDomainIPTypes(handler.Configuration, domain) {
+		if err := handler.updateIPByType(domain, ipType); err != nil {
+			errs = append(errs, err)
 		}
 	}
+
+	return errors.Join(errs...)
 }
 
-func (handler *Handler) UpdateIP(domain *settings.Domain) error {
-	ip := handler.ipManager.GetCurrentIP()
+// updateIPByType updates the records of the given IP family (A for IPV4, AAAA for IPV6).
+func (handler *Handler) updateIPByType(domain *settings.Domain, ipType string) error {
+	ip := handler.ipManager.GetCurrentIPByType(ipType)
 	if ip == "" {
 		if handler.Configuration.RunOnce {
-			return fmt.Errorf("fail to get current IP")
+			return fmt.Errorf("fail to get current %s address", ipType)
 		}
 		return nil
 	}
 
-	if ip == handler.cachedIP {
-		log.Debugf("IP (%s) matches cached IP (%s), skipping", ip, handler.cachedIP)
-		return nil
-	}
-
-	err := handler.updateDNS(domain, ip)
-	if err != nil {
+	if err := handler.updateDNS(domain, ip); err != nil {
 		if handler.Configuration.RunOnce {
 			return fmt.Errorf("%v: fail to update DNS", err)
 		}
 		log.Error(err)
-		return nil
 	}
-	handler.cachedIP = ip
-	log.Debugf("Cached IP address: %s", ip)
+
 	return nil
 }
 
+// updateDNS updates all the subdomains of the domain to the given IP. A failing
+// record doesn't stop the others from being updated: the failures are returned
+// together as one error made of *RecordError. In dry run mode, the changes are
+// only logged.
 func (handler *Handler) updateDNS(domain *settings.Domain, ip string) error {
 	var updatedDomains []string
+	var errs []error
+	var plannedChanges int
+	ipType := utils.GetIPType(ip)
+	recordType := utils.GetRecordType(ip)
 
 	// Get the appropriate provider for this domain
 	domainProvider, err := handler.getProviderForDomain(domain)
@@ -117,51 +135,192 @@ func (handler *Handler) updateDNS(domain *settings.Domain, ip string) error {
 	}
 
 	for _, subdomainName := range domain.SubDomains {
-		var hostname string
-
-		if subdomainName != utils.RootDomain {
-			hostname = subdomainName + "." + domain.DomainName
-		} else {
-			hostname = domain.DomainName
+		// don't start new updates once the handler is stopped
+		if err := handler.context().Err(); err != nil {
+			errs = append(errs, err)
+			break
 		}
 
-		lastIP, err := utils.ResolveDNS(hostname, handler.Configuration.Resolver, handler.Configuration.IPType)
-		if err != nil && (errors.Is(err, errEmptyResult) || errors.Is(err, errEmptyDomain)) {
-			log.Errorf("Failed to resolve DNS for domain: %s, error: %s", hostname, err)
+		// skip the subdomains which don't use the IP family of the current address
+		if !slices.Contains(utils.GetIPTypes(handler.Configuration.GetSubDomainIPType(domain, subdomainName)), ipType) {
 			continue
 		}
 
-		// check against the current known IP, if no change, skip update
-		if ip == lastIP {
-			log.Infof("Domain %s: IP is the same as cached one (%s). Skip update.", hostname, ip)
+		hostname := record.Hostname(domain.DomainName, subdomainName)
+		displayName := settings.ToUnicode(hostname)
+
+		previous, _ := handler.stateStore.Get(hostname, recordType)
+		forceUpdate := handler.stateStore.IsPushRequested(hostname, recordType) || handler.isForceUpdateDue(domain, previous)
+		// without an owner marker, an existing record of the zone is left alone unless adopted
+		tracked := handler.tracksOwnership(domain)
+		adopt := handler.Configuration.GetRecordOptions(domain.DomainName, subdomainName).Adopt
+		checkOwner := tracked && !adopt && !previous.Owned()
+
+		var lastIP string
+		var missing bool
+		if forceUpdate && !checkOwner {
+			lastIP = previous.Value
 		} else {
-			log.Infof("Updating domain: %s, current IP: %s, new IP: %s", hostname, lastIP, ip)
-			if err := domainProvider.UpdateIP(domain.DomainName, subdomainName, ip); err != nil {
-				return err
+			// the last update of this record succeeded with the same IP, nothing to do
+			if !forceUpdate && handler.stateStore.IsUpToDate(hostname, recordType, ip) {
+				log.Debugf("Domain %s: IP (%s) matches the last pushed one, skipping", displayName, ip)
+				continue
 			}
 
-			updatedDomains = append(updatedDomains, subdomainName)
+			lastIP, err = handler.lastValue(domainProvider, domain, subdomainName, hostname, recordType, ipType)
+			if err != nil && (errors.Is(err, errEmptyResult) || errors.Is(err, errEmptyDomain)) {
+				log.Errorf("Failed to resolve DNS for domain: %s, error: %s", displayName, err)
+				continue
+			}
+			// a record missing from the DNS is created as well by the providers which can't read it back
+			missing = errors.Is(err, errNoRecord) || utils.IsNotFound(err)
 
-			// execute webhook when it is enabled
-			if handler.Configuration.Webhook.Enabled {
-				if err := lib.GetWebhook(handler.Configuration).Execute(hostname, ip); err != nil {
-					return err
+			// check against the current known IP, if no change, skip update
+			if !forceUpdate && ip == lastIP {
+				log.Infof("Domain %s: IP is the same as cached one (%s). Skip update.", displayName, ip)
+				if !handler.Configuration.DryRun {
+					handler.stateStore.RecordSuccess(hostname, recordType, ip)
 				}
+				continue
+			}
+
+			if checkOwner && !missing {
+				err := fmt.Errorf("%s: %w, set adopt to take it over", hostname, utils.ErrNotOwned)
+				log.Errorf("Failed to update domain: %s, error: %s", displayName, err)
+				if !handler.Configuration.DryRun {
+					handler.stateStore.RecordFailure(hostname, recordType, 0, err)
+					errs = append(errs, &RecordError{Hostname: hostname, Err: err})
+				}
+				continue
+			}
+		}
+
+		if handler.Configuration.DryRun {
+			plannedChanges++
+			logPlannedChange(displayName, recordType, lastIP, ip, forceUpdate)
+			continue
+		}
+
+		if forceUpdate {
+			log.Infof("Domain %s: forcing the update of the IP (%s)", displayName, ip)
+		} else {
+			log.Infof("Updating domain: %s, current IP: %s, new IP: %s", displayName, lastIP, ip)
+		}
+
+		if err := handler.updateRecord(domainProvider, domain, subdomainName, hostname, ip); err != nil {
+			log.Errorf("Failed to update domain: %s, error: %s", displayName, err)
+			errs = append(errs, &RecordError{Hostname: hostname, Err: err})
+			continue
+		}
+		if missing {
+			handler.stateStore.RecordCreated(hostname, recordType)
+		} else if tracked && adopt && !previous.Owned() {
+			handler.stateStore.RecordAdopted(hostname, recordType)
+		}
+
+		// a forced update of an unchanged IP is neither notified nor sent to the webhook
+		if forceUpdate && previous.LastError == "" && previous.Value == ip {
+			continue
+		}
+
+		updatedDomains = append(updatedDomains, settings.ToUnicode(subdomainName))
+
+		// execute webhook when it is enabled
+		if handler.Configuration.Webhook.Enabled {
+			if err := lib.GetWebhook(handler.Configuration).Execute(hostname, ip); err != nil {
+				log.Errorf("Failed to execute webhook for domain: %s, error: %s", displayName, err)
+				errs = append(errs, &RecordError{Hostname: hostname, Err: fmt.Errorf("webhook: %w", err)})
 			}
 		}
 	}
 
+	if handler.Configuration.DryRun {
+		log.Infof("[dry-run] %d change(s) planned for the %s records of %s", plannedChanges, recordType, settings.ToUnicode(domain.DomainName))
+		return nil
+	}
+
 	if len(updatedDomains) > 0 {
 		providerName := handler.Configuration.GetDomainProvider(domain)
-		successMessage := fmt.Sprintf("[ %s ] of %s (via %s)", strings.Join(updatedDomains, ", "), domain.DomainName, providerName)
+		successMessage := fmt.Sprintf("[ %s ] of %s (%s via %s)", strings.Join(updatedDomains, ", "), settings.ToUnicode(domain.DomainName), ipType, providerName)
 		handler.notificationManager.Send(successMessage, ip)
 	}
 
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update %d record(s) of %s: %w", len(errs), settings.ToUnicode(domain.DomainName), errors.Join(errs...))
+	}
+
 	return nil
 }
 
+// logPlannedChange logs the change of a record which would be applied without the dry run mode.
+func logPlannedChange(hostname, recordType, lastIP, ip string, forceUpdate bool) {
+	switch {
+	case forceUpdate:
+		log.Infof("[dry-run] Would force update the %s record of %s to %s", recordType, hostname, ip)
+	case lastIP == "":
+		log.Infof("[dry-run] Would create the %s record of %s with %s", recordType, hostname, ip)
+	default:
+		log.Infof("[dry-run] Would update the %s record of %s from %s to %s", recordType, hostname, lastIP, ip)
+	}
+}
+
+// isForceUpdateDue returns true if the force update interval of the domain
+// elapsed since the last provider update of the record.
+func (handler *Handler) isForceUpdateDue(domain *settings.Domain, record state.RecordState) bool {
+	interval := handler.Configuration.GetDomainForceUpdateInterval(domain)
+	if interval <= 0 {
+		return false
+	}
+
+	return time.Since(record.LastPush) >= time.Duration(interval)*time.Second
+}
+
+// lastValue returns the current value of the record, read back from the
+// provider API when it supports it, or resolved through DNS otherwise.
+func (handler *Handler) lastValue(domainProvider provider.IDNSProviderV2, domain *settings.Domain, subdomainName, hostname, recordType, ipType string) (string, error) {
+	if reader, ok := domainProvider.(provider.IRecordReader); ok {
+		ctx, cancel := handler.attemptContext()
+		defer cancel()
+
+		rec, err := reader.GetRecord(ctx, domain.DomainName, subdomainName, recordType)
+		if err == nil {
+			if rec == nil {
+				log.Debugf("Domain %s: no %s record found at the provider", hostname, recordType)
+				return "", errNoRecord
+			}
+			return rec.Value, nil
+		}
+		log.Warnf("Failed to read back %s from the provider, falling back to a DNS lookup: %s", hostname, err)
+	}
+
+	return utils.ResolveDNS(hostname, handler.Configuration.Resolver, ipType)
+}
+
+// tracksOwnership returns true if the provider of the domain hosts a zone
+// shared with the records managed by hand, without marking the records of
+// GoDNS: their ownership is then kept in the state.
+func (handler *Handler) tracksOwnership(domain *settings.Domain) bool {
+	info, exists := registry.Get(handler.Configuration.GetProviderType(handler.Configuration.GetDomainProvider(domain)))
+	return exists && info.Capabilities.Zone && !info.Capabilities.Ownership
+}
+
+// context returns the context of the handler, cancelled when the handler is stopped.
+func (handler *Handler) context() context.Context {
+	if handler.ctx == nil {
+		return context.Background()
+	}
+	return handler.ctx
+}
+
+func (handler *Handler) callContext() context.Context {
+	if handler.callCtx == nil {
+		return context.Background()
+	}
+	return handler.callCtx
+}
+
 // getProviderForDomain returns the appropriate provider for a given domain.
-func (handler *Handler) getProviderForDomain(domain *settings.Domain) (provider.IDNSProvider, error) {
+func (handler *Handler) getProviderForDomain(domain *settings.Domain) (provider.IDNSProviderV2, error) {
 	// Multi-provider mode
 	if handler.Configuration.IsMultiProvider() {
 		providerName := handler.Configuration.GetDomainProvider(domain)
@@ -2,36 +2,24 @@ package handler
 
 import (
 	"context"
-	"sync"
-	"sync/atomic"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
-	"time"
 
 	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/state"
+	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/TimothyYe/godns/pkg/lib"
 )
 
-// fakeProvider counts UpdateIP calls and is safe for concurrent use.
-type fakeProvider struct {
-	calls atomic.Int32
-}
-
-func (f *fakeProvider) Init(_ *settings.Settings) {}
-func (f *fakeProvider) UpdateIP(_, _, _ string) error {
-	f.calls.Add(1)
-	return nil
-}
-
 // newTestHandler wires a Handler to a fake provider and a real IPHelper
-// configured without IP sources, so GetCurrentIP returns "" and UpdateIP
-// exits early — letting the loop's lifecycle be exercised without any
-// network or external dependency.
-func newTestHandler(_ *testing.T, fp provider.IDNSProvider) *Handler {
+// configured without IP sources, so the tests don't depend on the network.
+func newTestHandler(_ *testing.T, fp provider.IDNSProviderV2) *Handler {
 	conf := &settings.Settings{
-		// Interval is in seconds; pick something long enough that the
-		// ticker never fires during these tests — the lifecycle paths
-		// (initial run + ctx.Done) are what we care about.
 		Interval: 60,
 		RunOnce:  false,
 	}
@@ -39,76 +27,266 @@ func newTestHandler(_ *testing.T, fp provider.IDNSProvider) *Handler {
 		Configuration: conf,
 		dnsProvider:   fp,
 		ipManager:     lib.GetIPHelperInstance(conf),
+		stateStore:    state.NewStore(),
+	}
+}
+
+// partialProvider fails the update of the listed subdomains.
+type partialProvider struct {
+	failing map[string]bool
+	updated []string
+}
+
+func (f *partialProvider) Init(_ *settings.Settings) {}
+func (f *partialProvider) UpdateIP(_ context.Context, _, subdomain, _ string) error {
+	if f.failing[subdomain] {
+		return errors.New("provider error")
+	}
+	f.updated = append(f.updated, subdomain)
+	return nil
+}
+
+// fakeNotificationManager records the sent messages.
+type fakeNotificationManager struct {
+	messages []string
+}
+
+func (f *fakeNotificationManager) Send(msg, _ string) {
+	f.messages = append(f.messages, msg)
+}
+
+func TestUpdateDNS_PartialFailure(t *testing.T) {
+	fp := &partialProvider{failing: map[string]bool{"b": true}}
+	nm := &fakeNotificationManager{}
+	h := newTestHandler(t, fp)
+	h.notificationManager = nm
+
+	domain := &settings.Domain{DomainName: "example.invalid", SubDomains: []string{"a", "b", "c"}}
+	err := h.updateDNS(domain, "192.0.2.1")
+	if err == nil {
+		t.Fatal("expected an error for the failing record")
+	}
+
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Hostname != "b.example.invalid" {
+		t.Fatalf("expected a RecordError for b.example.invalid, got: %v", err)
+	}
+
+	if !slices.Equal(fp.updated, []string{"a", "c"}) {
+		t.Errorf("expected a and c to be updated, got %v", fp.updated)
+	}
+
+	if len(nm.messages) != 1 || !strings.Contains(nm.messages[0], "[ a, c ]") {
+		t.Errorf("expected one notification for a and c, got %v", nm.messages)
+	}
+
+	if !h.stateStore.IsUpToDate("a.example.invalid", "A", "192.0.2.1") {
+		t.Error("expected a.example.invalid to be up to date")
+	}
+	if h.stateStore.IsUpToDate("b.example.invalid", "A", "192.0.2.1") {
+		t.Error("expected b.example.invalid not to be up to date")
+	}
+}
+
+func TestUpdateDNS_ForceUpdate(t *testing.T) {
+	fp := &partialProvider{}
+	nm := &fakeNotificationManager{}
+	h := newTestHandler(t, fp)
+	h.notificationManager = nm
+	h.Configuration.ForceUpdateInterval = 3600
+
+	domain := &settings.Domain{DomainName: "example.invalid", SubDomains: []string{"a", "b"}}
+
+	// a was pushed recently, b was only confirmed by a DNS lookup
+	h.stateStore.RecordPush("a.example.invalid", "A", "192.0.2.1")
+	h.stateStore.RecordSuccess("b.example.invalid", "A", "192.0.2.1")
+
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !slices.Equal(fp.updated, []string{"b"}) {
+		t.Errorf("expected only b to be force updated, got %v", fp.updated)
+	}
+
+	if len(nm.messages) != 0 {
+		t.Errorf("expected no notification for an unchanged IP, got %v", nm.messages)
+	}
+
+	// without a force update interval, the up to date records are skipped
+	fp.updated = nil
+	h.Configuration.ForceUpdateInterval = 0
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(fp.updated) != 0 {
+		t.Errorf("expected no update without a force update interval, got %v", fp.updated)
+	}
+
+	// a domain can opt out of the global force update interval
+	h.Configuration.ForceUpdateInterval = 3600
+	h.stateStore.RecordSuccess("c.example.invalid", "A", "192.0.2.1")
+	optOut := &settings.Domain{DomainName: "example.invalid", SubDomains: []string{"c"}, ForceUpdateInterval: settings.ForceUpdateDisabled}
+	if err := h.updateDNS(optOut, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(fp.updated) != 0 {
+		t.Errorf("expected no update of a domain opting out of the force update, got %v", fp.updated)
+	}
+	h.Configuration.ForceUpdateInterval = 0
+
+	// a push request forces the update of all the records
+	h.stateStore.RequestPush()
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !slices.Equal(fp.updated, []string{"a", "b"}) {
+		t.Errorf("expected a and b to be force updated, got %v", fp.updated)
 	}
 }
 
-// TestLoopUpdateIP_ReturnsOnCancel verifies that cancelling the context
-// causes LoopUpdateIP to return promptly. Exercises the ctx.Done branch
-// and indirectly the deferred ticker.Stop introduced in §1.4 — if the
-// ticker leaked, -race would not catch it but the function still must
-// return within the timeout.
-func TestLoopUpdateIP_ReturnsOnCancel(t *testing.T) {
-	h := newTestHandler(t, &fakeProvider{})
-
-	ctx, cancel := context.WithCancel(context.Background())
-	done := make(chan error, 1)
-	go func() {
-		done <- h.LoopUpdateIP(ctx, &settings.Domain{DomainName: "example.com"})
-	}()
-
-	// Give the goroutine a moment to enter its select.
-	time.Sleep(20 * time.Millisecond)
-	cancel()
-
-	select {
-	case err := <-done:
-		if err != nil {
-			t.Fatalf("LoopUpdateIP returned error: %v", err)
-		}
-	case <-time.After(2 * time.Second):
-		t.Fatal("LoopUpdateIP did not return within 2s of ctx cancellation")
+func TestUpdateDNS_DryRun(t *testing.T) {
+	fp := &partialProvider{}
+	nm := &fakeNotificationManager{}
+	h := newTestHandler(t, fp)
+	h.notificationManager = nm
+	h.Configuration.DryRun = true
+	h.Configuration.ForceUpdateInterval = 3600
+
+	domain := &settings.Domain{DomainName: "example.invalid", SubDomains: []string{"a", "b"}}
+	h.stateStore.RecordSuccess("b.example.invalid", "A", "192.0.2.1")
+
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(fp.updated) != 0 {
+		t.Errorf("expected no provider update in dry run mode, got %v", fp.updated)
+	}
+	if len(nm.messages) != 0 {
+		t.Errorf("expected no notification in dry run mode, got %v", nm.messages)
+	}
+	if _, exists := h.stateStore.Get("a.example.invalid", "A"); exists {
+		t.Error("expected no state change in dry run mode")
 	}
 }
 
-// TestLoopUpdateIP_ConcurrentShutdown spawns several LoopUpdateIP
-// goroutines over independent domains sharing a context, and asserts
-// that they all exit cleanly when the context is cancelled. Covers both
-// the "concurrent provider updates" and "graceful shutdown / context
-// cancellation paths" gaps from optimization.md §7.
-func TestLoopUpdateIP_ConcurrentShutdown(t *testing.T) {
-	h := newTestHandler(t, &fakeProvider{})
-
-	ctx, cancel := context.WithCancel(context.Background())
-	domains := []settings.Domain{
-		{DomainName: "a.example.com"},
-		{DomainName: "b.example.com"},
-		{DomainName: "c.example.com"},
-		{DomainName: "d.example.com"},
-	}
-
-	var wg sync.WaitGroup
-	for i := range domains {
-		d := domains[i]
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			_ = h.LoopUpdateIP(ctx, &d)
-		}()
-	}
-
-	// Let all goroutines reach their select.
-	time.Sleep(20 * time.Millisecond)
-	cancel()
-
-	finished := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(finished)
-	}()
-
-	select {
-	case <-finished:
-	case <-time.After(2 * time.Second):
-		t.Fatal("not all LoopUpdateIP goroutines returned within 2s of cancel")
+// readerProvider reads back the records from a map of hostname to value.
+type readerProvider struct {
+	partialProvider
+	records map[string]string
+}
+
+func (f *readerProvider) GetRecords(_ context.Context, _ string) ([]record.Record, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *readerProvider) GetRecord(_ context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	name := record.Hostname(domainName, subdomainName)
+	value, exists := f.records[name]
+	if !exists {
+		return nil, nil
+	}
+	return &record.Record{Name: name, Type: recordType, Value: value}, nil
+}
+
+func TestUpdateDNS_ReadBack(t *testing.T) {
+	fp := &readerProvider{records: map[string]string{
+		"a.example.invalid": "192.0.2.1",
+		"b.example.invalid": "192.0.2.2",
+	}}
+	h := newTestHandler(t, fp)
+	h.notificationManager = &fakeNotificationManager{}
+
+	domain := &settings.Domain{DomainName: "example.invalid", SubDomains: []string{"a", "b", "c"}}
+
+	// the drift is planned from the records read back in dry run mode too
+	h.Configuration.DryRun = true
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fp.updated) != 0 {
+		t.Errorf("expected no update in dry run mode, got %v", fp.updated)
+	}
+
+	h.Configuration.DryRun = false
+	if err := h.updateDNS(domain, "192.0.2.1"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// a is up to date at the provider, b has drifted and c is missing
+	if !slices.Equal(fp.updated, []string{"b", "c"}) {
+		t.Errorf("expected b and c to be updated, got %v", fp.updated)
+	}
+	if !h.stateStore.IsUpToDate("a.example.invalid", "A", "192.0.2.1") {
+		t.Error("expected a.example.invalid to be confirmed up to date")
+	}
+
+	// only the missing record is created by GoDNS
+	if c, _ := h.stateStore.Get("c.example.invalid", "A"); !c.Created {
+		t.Error("expected c.example.invalid to be marked as created")
+	}
+	if b, _ := h.stateStore.Get("b.example.invalid", "A"); b.Created {
+		t.Error("expected b.example.invalid not to be marked as created")
+	}
+}
+
+func init() {
+	// a provider hosting whole zones, without owner marker
+	registry.Register(registry.Provider{
+		Name:         "ZoneTest",
+		Capabilities: registry.Capabilities{Zone: true},
+		New:          func() registry.Instance { return &readerProvider{} },
+	})
+}
+
+func TestUpdateDNS_Ownership(t *testing.T) {
+	fp := &readerProvider{records: map[string]string{"a.example.invalid": "192.0.2.1"}}
+	h := newTestHandler(t, fp)
+	h.notificationManager = &fakeNotificationManager{}
+	h.Configuration.Provider = "ZoneTest"
+	h.Configuration.Domains = []settings.Domain{{DomainName: "example.invalid", SubDomains: []string{"a", "b"}}}
+	domain := &h.Configuration.Domains[0]
+
+	// a existed before GoDNS and is left alone, b is missing and created
+	err := h.updateDNS(domain, "192.0.2.9")
+	if !errors.Is(err, utils.ErrNotOwned) {
+		t.Errorf("expected a not owned error, got %v", err)
+	}
+	if !slices.Equal(fp.updated, []string{"b"}) {
+		t.Errorf("expected only b to be updated, got %v", fp.updated)
+	}
+
+	// the created record is managed from then on
+	fp.records["b.example.invalid"] = "192.0.2.9"
+	fp.updated = nil
+	_ = h.updateDNS(domain, "192.0.2.10")
+	if !slices.Equal(fp.updated, []string{"b"}) {
+		t.Errorf("expected only b to be updated again, got %v", fp.updated)
+	}
+
+	// the existing record is taken over once adopted
+	domain.Adopt = true
+	fp.updated = nil
+	if err := h.updateDNS(domain, "192.0.2.10"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !slices.Equal(fp.updated, []string{"a"}) {
+		t.Errorf("expected a to be adopted, got %v", fp.updated)
+	}
+	if a, _ := h.stateStore.Get("a.example.invalid", "A"); !a.Adopted || a.Created {
+		t.Errorf("expected a.example.invalid to be marked as adopted only, got %+v", a)
+	}
+
+	domain.Adopt = false
+	fp.updated = nil
+	if err := h.updateDNS(domain, "192.0.2.11"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !slices.Equal(fp.updated, []string{"a", "b"}) {
+		t.Errorf("expected a and b to be updated, got %v", fp.updated)
 	}
 }
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// updateRecord pushes the IP of a single record through the provider, retrying
+// with exponential backoff according to the configured retry policy.
+func (handler *Handler) updateRecord(domainProvider provider.IDNSProviderV2, domain *settings.Domain, subdomainName, hostname, ip string) error {
+	policy := handler.Configuration.Retry
+	recordType := utils.GetRecordType(ip)
+	ctx := handler.context()
+
+	defer handler.stateStore.StartUpdate(hostname, recordType)()
+
+	for attempt := 1; ; attempt++ {
+		err := handler.callProvider(domainProvider, domain.DomainName, subdomainName, ip)
+		if err == nil {
+			handler.stateStore.RecordPush(hostname, recordType, ip)
+			return nil
+		}
+		handler.stateStore.RecordFailure(hostname, recordType, utils.StatusCode(err), err)
+
+		// retrying won't make the record owned
+		if attempt >= policy.MaxAttempts || errors.Is(err, utils.ErrNotOwned) {
+			return err
+		}
+
+		delay := retryDelay(policy, attempt, err)
+		log.Warnf("Failed to update %s (attempt %d/%d): %s, will retry in %s", hostname, attempt, policy.MaxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// callProvider runs a single attempt, bounded by the retry timeout.
+func (handler *Handler) callProvider(domainProvider provider.IDNSProviderV2, domainName, subdomainName, ip string) error {
+	ctx, cancel := handler.attemptContext()
+	defer cancel()
+
+	return domainProvider.UpdateIP(ctx, domainName, subdomainName, ip)
+}
+
+// attemptContext returns the context of a single provider call, bounded by the retry timeout.
+func (handler *Handler) attemptContext() (context.Context, context.CancelFunc) {
+	if timeout := handler.Configuration.Retry.Timeout; timeout > 0 {
+		return context.WithTimeout(handler.callContext(), time.Duration(timeout)*time.Second)
+	}
+	return context.WithCancel(handler.callContext())
+}
+
+// retryDelay returns the delay before the next attempt: the base delay doubled
+// on every attempt, capped by the max delay and randomized by the jitter.
+// A Retry-After delay requested by the provider takes precedence, but it is
+// capped by the max delay too, so that a provider can't stall a record.
+func retryDelay(policy settings.Retry, attempt int, err error) time.Duration {
+	maxDelay := float64(time.Duration(policy.MaxDelay) * time.Second)
+
+	var retryAfterErr *utils.RetryAfterError
+	if errors.As(err, &retryAfterErr) && retryAfterErr.RetryAfter > 0 {
+		return min(retryAfterErr.RetryAfter, time.Duration(maxDelay))
+	}
+
+	delay := float64(time.Duration(policy.BaseDelay)*time.Second) * math.Pow(2, float64(attempt-1))
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if policy.Jitter > 0 {
+		delay += (rand.Float64()*2 - 1) * policy.Jitter * delay
+	}
+
+	return time.Duration(delay)
+}
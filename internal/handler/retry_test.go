@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/state"
+	"github.com/TimothyYe/godns/internal/utils"
+)
+
+// flakyProvider fails the first `failures` calls with a rate limit error
+// asking to retry after 1ms, then succeeds.
+type flakyProvider struct {
+	calls    atomic.Int32
+	failures int32
+}
+
+func (f *flakyProvider) Init(_ *settings.Settings) {}
+func (f *flakyProvider) UpdateIP(_ context.Context, _, _, _ string) error {
+	if f.calls.Add(1) <= f.failures {
+		return &utils.RetryAfterError{StatusCode: 429, RetryAfter: time.Millisecond}
+	}
+	return nil
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := settings.Retry{MaxAttempts: 5, BaseDelay: 2, MaxDelay: 10}
+
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := retryDelay(policy, i+1, errors.New("failed")); got != want {
+			t.Errorf("attempt %d: expected delay %s, got %s", i+1, want, got)
+		}
+	}
+
+	retryAfterErr := &utils.RetryAfterError{StatusCode: 429, RetryAfter: 7 * time.Second}
+	if got := retryDelay(policy, 1, retryAfterErr); got != 7*time.Second {
+		t.Errorf("Retry-After should take precedence, got %s", got)
+	}
+
+	retryAfterErr.RetryAfter = 24 * time.Hour
+	if got := retryDelay(policy, 1, retryAfterErr); got != 10*time.Second {
+		t.Errorf("Retry-After should be capped by the max delay, got %s", got)
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := retryDelay(policy, 1, errors.New("failed"))
+		if got < time.Second || got > 3*time.Second {
+			t.Fatalf("jittered delay %s out of the [1s, 3s] range", got)
+		}
+	}
+}
+
+func TestUpdateRecordRetries(t *testing.T) {
+	fp := &flakyProvider{failures: 2}
+	h := &Handler{
+		Configuration: &settings.Settings{Retry: settings.Retry{MaxAttempts: 3, BaseDelay: 60, MaxDelay: 60}},
+		stateStore:    state.NewStore(),
+	}
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}}
+
+	if err := h.updateRecord(fp, domain, "www", "www.example.com", "1.2.3.4"); err != nil {
+		t.Fatalf("update should succeed on the third attempt, got: %v", err)
+	}
+	if calls := fp.calls.Load(); calls != 3 {
+		t.Errorf("expected 3 provider calls, got %d", calls)
+	}
+	if !h.stateStore.IsUpToDate("www.example.com", utils.IPTypeA, "1.2.3.4") {
+		t.Error("record should be up to date after a successful retry")
+	}
+	if record, _ := h.stateStore.Get("www.example.com", utils.IPTypeA); record.Status != state.StatusUpdated || record.StatusCode != 0 {
+		t.Errorf("expected the failure status to be cleared, got %q (%d)", record.Status, record.StatusCode)
+	}
+}
+
+func TestUpdateRecordGivesUp(t *testing.T) {
+	fp := &flakyProvider{failures: 5}
+	h := &Handler{
+		Configuration: &settings.Settings{Retry: settings.Retry{MaxAttempts: 2}},
+		stateStore:    state.NewStore(),
+	}
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}}
+
+	if err := h.updateRecord(fp, domain, "www", "www.example.com", "1.2.3.4"); err == nil {
+		t.Fatal("update should fail once max attempts are reached")
+	}
+	if calls := fp.calls.Load(); calls != 2 {
+		t.Errorf("expected 2 provider calls, got %d", calls)
+	}
+
+	record, _ := h.stateStore.Get("www.example.com", utils.IPTypeA)
+	if record.Attempts != 2 {
+		t.Errorf("expected 2 failed attempts in the state, got %d", record.Attempts)
+	}
+	if record.Status != state.StatusFailed || record.StatusCode != 429 {
+		t.Errorf("expected the 429 status of the provider in the state, got %q (%d)", record.Status, record.StatusCode)
+	}
+}
+
+// foreignProvider refuses to modify a record which GoDNS doesn't manage.
+type foreignProvider struct {
+	calls atomic.Int32
+}
+
+func (f *foreignProvider) Init(_ *settings.Settings) {}
+func (f *foreignProvider) UpdateIP(_ context.Context, _, _, _ string) error {
+	f.calls.Add(1)
+	return utils.ErrNotOwned
+}
+
+func TestUpdateRecordNotOwned(t *testing.T) {
+	fp := &foreignProvider{}
+	h := &Handler{
+		Configuration: &settings.Settings{Retry: settings.Retry{MaxAttempts: 3, BaseDelay: 60, MaxDelay: 60}},
+		stateStore:    state.NewStore(),
+	}
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}}
+
+	if err := h.updateRecord(fp, domain, "www", "www.example.com", "1.2.3.4"); !errors.Is(err, utils.ErrNotOwned) {
+		t.Fatalf("expected the record to be refused, got: %v", err)
+	}
+	if calls := fp.calls.Load(); calls != 1 {
+		t.Errorf("expected a single provider call, got %d", calls)
+	}
+}
+
+// hangingProvider blocks until the context of the call is done.
+type hangingProvider struct{}
+
+func (hangingProvider) Init(_ *settings.Settings) {}
+func (hangingProvider) UpdateIP(ctx context.Context, _, _, _ string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestUpdateRecordTimeout(t *testing.T) {
+	h := &Handler{
+		Configuration: &settings.Settings{Retry: settings.Retry{MaxAttempts: 1, Timeout: 1}},
+		stateStore:    state.NewStore(),
+	}
+	domain := &settings.Domain{DomainName: "example.com", SubDomains: []string{"www"}}
+
+	err := h.updateRecord(hangingProvider{}, domain, "www", "www.example.com", "1.2.3.4")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the attempt to time out, got %v", err)
+	}
+
+	// the call context interrupts the calls in progress regardless of the timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h.Configuration.Retry.Timeout = 0
+	h.SetCallContext(ctx)
+	if err := h.updateRecord(hangingProvider{}, domain, "www", "www.example.com", "1.2.3.4"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the call to be interrupted, got %v", err)
+	}
+}
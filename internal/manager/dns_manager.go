@@ -7,29 +7,45 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/TimothyYe/godns/internal/handler"
 	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/scheduler"
 	"github.com/TimothyYe/godns/internal/server"
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/state"
 	"github.com/TimothyYe/godns/internal/utils"
+	"github.com/TimothyYe/godns/pkg/lib"
 	"github.com/fsnotify/fsnotify"
 	log "github.com/sirupsen/logrus"
 )
 
 type DNSManager struct {
-	config      *settings.Settings
-	handler     *handler.Handler
-	provider    provider.IDNSProvider            // Legacy single provider (for backward compatibility)
-	providers   map[string]provider.IDNSProvider // Multi-provider support
-	ctx         context.Context
-	cancel      context.CancelFunc
+	config     *settings.Settings
+	handler    *handler.Handler
+	provider   provider.IDNSProviderV2            // Legacy single provider (for backward compatibility)
+	providers  map[string]provider.IDNSProviderV2 // Multi-provider support
+	stateStore *state.Store                       // Per-record update state, kept across restarts
+	scheduler  *scheduler.Scheduler
+	// updates tracks the goroutines running the DNS updates of the current lifecycle
+	updates *sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	// engineCtx is cancelled when the DNS updates are stopped, e.g. on a reload of the global settings
+	engineCtx    context.Context
+	engineCancel context.CancelFunc
+	// callsCtx is only cancelled to interrupt the provider calls still in progress after the shutdown timeout
+	callsCtx    context.Context
+	abortCalls  context.CancelFunc
 	watcher     *fsnotify.Watcher
 	server      *server.Server
 	configPath  string
 	defaultAddr string
+	// dryRun forces the dry run mode across configuration reloads
+	dryRun bool
 	// restartMu serializes Restart() — a single config save can fire multiple
 	// fsnotify events, and overlapping restarts leave the manager in a
 	// partially-initialized state.
@@ -76,6 +92,7 @@ func (manager *DNSManager) startServer() {
 			SetAuthInfo(manager.config.WebPanel.Username, manager.config.WebPanel.Password).
 			SetConfig(manager.config).
 			SetConfigPath(manager.configPath).
+			SetScheduler(manager.scheduler).
 			Build()
 
 		srv := manager.server
@@ -119,24 +136,9 @@ func (manager *DNSManager) startMonitor() {
 					// read the file and update the configuration
 					configFile := getFileName(manager.configPath)
 					if event.Name == configFile {
-						// Load settings from configs file
-						newConfig := &settings.Settings{}
-						if err := settings.LoadSettings(manager.configPath, newConfig); err != nil {
-							log.Errorf("Failed to reload configuration: %s", err)
-							continue
+						if err := manager.ReloadConfig(); err != nil {
+							log.Error(err)
 						}
-
-						// validate the new configuration
-						if err := utils.CheckSettings(newConfig); err != nil {
-							log.Errorf("Failed to validate the new configuration: %s", err)
-							continue
-						}
-
-						manager.config = newConfig
-						manager.Restart()
-						// Restart() cancels ctx and closes watcher — exit so
-						// the freshly-spawned monitor owns the new lifecycle.
-						return
 					}
 				}
 			case err, ok := <-watcher.Errors:
@@ -162,6 +164,34 @@ func (manager *DNSManager) initManager() error {
 	manager.ctx = ctx
 	manager.cancel = cancel
 
+	if err := manager.initEngine(); err != nil {
+		return err
+	}
+
+	// if RunOnce is true, we don't need to create a file watcher and start the internal HTTP server
+	if !manager.config.RunOnce {
+		// create a new file watcher
+		log.Debug("Creating the new file watcher...")
+		var err error
+		manager.watcher, err = fsnotify.NewWatcher()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// monitor the configuration file changes
+		manager.startMonitor()
+		// start the internal HTTP server
+		manager.startServer()
+	}
+	return nil
+}
+
+// initEngine creates the providers, the handler and the scheduler of the DNS updates.
+func (manager *DNSManager) initEngine() error {
+	manager.engineCtx, manager.engineCancel = context.WithCancel(manager.ctx)
+	manager.callsCtx, manager.abortCalls = context.WithCancel(context.Background())
+	manager.updates = &sync.WaitGroup{}
+
 	// Initialize providers based on configuration
 	if manager.config.IsMultiProvider() {
 		log.Info("Creating DNS handlers with multiple providers")
@@ -184,61 +214,198 @@ func (manager *DNSManager) initManager() error {
 		manager.provider = dnsProvider
 	}
 
-	manager.handler = &handler.Handler{}
-	manager.handler.SetContext(manager.ctx)
-	manager.handler.SetConfiguration(manager.config)
+	// keep the state store across restarts, unless the state file has changed
+	if manager.stateStore == nil || manager.stateStore.Path() != manager.config.StateFile {
+		manager.stateStore = manager.loadStateStore()
+	}
+
+	manager.handler = manager.newHandler(manager.providers, manager.provider)
+
+	// if RunOnce is true, the domains are updated once without the scheduler
+	if !manager.config.RunOnce {
+		var err error
+		if manager.scheduler, err = manager.newScheduler(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newHandler creates a handler of the current configuration, using the given providers in
+// multi-provider mode, or the given legacy provider otherwise.
+func (manager *DNSManager) newHandler(providers map[string]provider.IDNSProviderV2, legacyProvider provider.IDNSProviderV2) *handler.Handler {
+	domainHandler := &handler.Handler{}
+	domainHandler.SetContext(manager.engineCtx)
+	domainHandler.SetCallContext(manager.callsCtx)
+	domainHandler.SetConfiguration(manager.config)
+	domainHandler.SetStateStore(manager.stateStore)
 
 	// Set provider(s) on handler
 	if manager.config.IsMultiProvider() {
-		manager.handler.SetProviders(manager.providers)
+		domainHandler.SetProviders(providers)
 	} else {
-		manager.handler.SetProvider(manager.provider)
+		domainHandler.SetProvider(legacyProvider)
 	}
 
-	manager.handler.Init()
+	domainHandler.Init()
+	return domainHandler
+}
 
-	// if RunOnce is true, we don't need to create a file watcher and start the internal HTTP server
-	if !manager.config.RunOnce {
-		// create a new file watcher
-		log.Debug("Creating the new file watcher...")
-		var err error
-		manager.watcher, err = fsnotify.NewWatcher()
-		if err != nil {
-			log.Fatal(err)
-		}
+// loadStateStore opens the state file if it is configured, or falls back to an in-memory store.
+func (manager *DNSManager) loadStateStore() *state.Store {
+	if manager.config.StateFile == "" {
+		return state.NewStore()
+	}
 
-		// monitor the configuration file changes
-		manager.startMonitor()
-		// start the internal HTTP server
-		manager.startServer()
+	store, err := state.LoadStore(manager.config.StateFile)
+	if err != nil {
+		// a broken state file only costs a DNS lookup per record, don't refuse to start
+		log.Warnf("Failed to load the state file, starting with an empty state: %s", err)
 	}
+
+	return store
+}
+
+// ReloadConfig loads and validates the configuration file, then applies it.
+func (manager *DNSManager) ReloadConfig() error {
+	newConfig := &settings.Settings{}
+	if err := settings.LoadSettings(manager.configPath, newConfig); err != nil {
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	if manager.dryRun {
+		newConfig.DryRun = true
+	}
+
+	if err := utils.ExpandHostnames(newConfig, utils.NewZoneLookup(newConfig.Resolver)); err != nil {
+		return fmt.Errorf("failed to validate the new configuration: %w", err)
+	}
+
+	// validate the new configuration
+	if err := utils.CheckSettings(newConfig); err != nil {
+		return fmt.Errorf("failed to validate the new configuration: %w", err)
+	}
+
+	manager.Reload(newConfig)
 	return nil
 }
 
+// ForceUpdate updates all the records right away through their provider, even the ones up to date.
+func (manager *DNSManager) ForceUpdate() {
+	manager.restartMu.Lock()
+	defer manager.restartMu.Unlock()
+
+	manager.stateStore.RequestPush()
+	if manager.scheduler != nil {
+		manager.scheduler.Trigger()
+	}
+}
+
+// SetDryRun forces the dry run mode, whatever the dry_run setting of the configuration file.
+func (manager *DNSManager) SetDryRun(dryRun bool) {
+	manager.dryRun = dryRun
+}
+
 func (manager *DNSManager) Run() {
 	if len(manager.config.Domains) == 0 {
 		log.Info("No domain is configured, please check your configuration file")
-		return
+		// keep the scheduler running for the domains added by a reload
+		if manager.config.RunOnce {
+			return
+		}
 	}
 
-	for _, domain := range manager.config.Domains {
-		if manager.config.RunOnce {
+	if manager.config.DryRun {
+		log.Info("Dry run mode is enabled, the DNS changes are only logged")
+	}
+
+	if manager.config.RunOnce {
+		for _, domain := range manager.config.Domains {
 			err := manager.handler.UpdateIP(&domain)
 			if err != nil {
 				log.Error("Error during execution:", err)
 				os.Exit(1)
 			}
-		} else {
-			// pass the context to the goroutine
-			go manager.handler.LoopUpdateIP(manager.ctx, &domain)
 		}
+		os.Exit(0)
 	}
 
-	if manager.config.RunOnce {
-		os.Exit(0)
+	manager.startEngine()
+}
+
+// startEngine starts the scheduler of the DNS updates.
+func (manager *DNSManager) startEngine() {
+	ctx, updateScheduler, updates := manager.engineCtx, manager.scheduler, manager.updates
+	updates.Add(1)
+	go func() {
+		defer updates.Done()
+		updateScheduler.Run(ctx)
+	}()
+
+	if manager.config.IPInterface != "" {
+		go manager.watchIPChanges(ctx, updateScheduler)
 	}
 }
 
+// stopEngine stops the DNS updates, waiting for the in-flight ones.
+func (manager *DNSManager) stopEngine() {
+	manager.engineCancel()
+	manager.waitForUpdates()
+}
+
+// watchIPChanges updates all the domains as soon as the IP helper reports a
+// change of the current IP, instead of waiting for their next scheduled run.
+func (manager *DNSManager) watchIPChanges(ctx context.Context, updateScheduler *scheduler.Scheduler) {
+	changes := lib.GetIPHelperInstance(manager.config).Changes()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+			log.Info("Current IP changed, updating all the domains")
+			updateScheduler.Trigger()
+		}
+	}
+}
+
+// newScheduler creates the scheduler of the domain updates.
+func (manager *DNSManager) newScheduler() (*scheduler.Scheduler, error) {
+	updateScheduler := scheduler.New(manager.config.Scheduler)
+
+	for i := range manager.config.Domains {
+		job, err := manager.domainJob(manager.handler, &manager.config.Domains[i])
+		if err != nil {
+			return nil, err
+		}
+		updateScheduler.Add(job)
+	}
+
+	return updateScheduler, nil
+}
+
+// domainJob creates the scheduler job updating a domain with the given handler.
+func (manager *DNSManager) domainJob(domainHandler *handler.Handler, domain *settings.Domain) (scheduler.Job, error) {
+	var schedule scheduler.Schedule = scheduler.Every(time.Duration(manager.config.GetDomainInterval(domain)) * time.Second)
+	if domain.Schedule != "" {
+		cron, err := scheduler.ParseCron(domain.Schedule)
+		if err != nil {
+			return scheduler.Job{}, fmt.Errorf("failed to parse the schedule of domain %s: %w", domain.DomainName, err)
+		}
+		schedule = cron
+	}
+
+	return scheduler.Job{
+		ID:       manager.config.DomainKey(domain),
+		Name:     domain.DomainName,
+		Group:    manager.config.GetDomainProvider(domain),
+		Schedule: schedule,
+		Run: func() error {
+			return domainHandler.UpdateIP(domain)
+		},
+	}, nil
+}
+
 func (manager *DNSManager) Stop() {
 	manager.cancel()
 	// close the file watcher
@@ -250,6 +417,40 @@ func (manager *DNSManager) Stop() {
 	if manager.server != nil {
 		manager.server.Stop()
 	}
+
+	manager.waitForUpdates()
+}
+
+// waitForUpdates waits for the in-flight DNS updates to finish, up to the
+// shutdown timeout, then interrupts and reports the remaining ones.
+func (manager *DNSManager) waitForUpdates() {
+	done := make(chan struct{})
+	updates := manager.updates
+	defer manager.abortCalls()
+	go func() {
+		updates.Wait()
+		close(done)
+	}()
+
+	var deadline <-chan time.Time
+	timeout := time.Duration(manager.config.ShutdownTimeout) * time.Second
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case <-done:
+		log.Debug("All the DNS updates are finished")
+	case <-deadline:
+		records := manager.stateStore.InFlight()
+		if len(records) == 0 {
+			log.Warnf("DNS updates didn't finish within the shutdown timeout of %s", timeout)
+			return
+		}
+		log.Warnf("DNS updates didn't finish within the shutdown timeout of %s, interrupted records: %s", timeout, strings.Join(records, ", "))
+	}
 }
 
 func (manager *DNSManager) Restart() {
@@ -262,9 +463,6 @@ func (manager *DNSManager) Restart() {
 	log.Info("Restarting DNS manager...")
 	manager.Stop()
 
-	// wait for the goroutines to exit
-	time.Sleep(200 * time.Millisecond)
-
 	// re-init the manager
 	if err := manager.initManager(); err != nil {
 		log.Fatalf("Error during DNS manager restarting: %s", err)
@@ -1,13 +1,21 @@
 package manager
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/TimothyYe/godns/internal/provider/cloudflare"
+	"github.com/TimothyYe/godns/internal/server/controllers"
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/gofiber/fiber/v2"
 )
 
 // writeTempConfig drops a minimal config file in a temp dir so the
@@ -103,8 +111,8 @@ func TestRestart_SerializedConcurrent(t *testing.T) {
 		close(finished)
 	}()
 
-	// Each Restart sleeps ~200ms; with serialization, total wall time
-	// scales with goroutines. 10s is plenty even on a busy CI box.
+	// With serialization, total wall time scales with goroutines.
+	// 10s is plenty even on a busy CI box.
 	select {
 	case <-finished:
 	case <-time.After(10 * time.Second):
@@ -124,3 +132,178 @@ func TestRestart_SerializedConcurrent(t *testing.T) {
 		t.Error("handler is nil after concurrent restarts")
 	}
 }
+
+// TestStop_ShutdownTimeout verifies that Stop doesn't wait forever for an
+// update which never finishes, and returns once the shutdown timeout elapsed.
+func TestStop_ShutdownTimeout(t *testing.T) {
+	m := newTestManager(t)
+	m.config.ShutdownTimeout = 1
+
+	// simulate an update stuck in a provider call
+	m.updates.Add(1)
+	defer m.updates.Done()
+
+	start := time.Now()
+	m.Stop()
+
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 5*time.Second {
+		t.Errorf("expected Stop to return after the 1s shutdown timeout, took %s", elapsed)
+	}
+}
+
+// TestReload_Incremental verifies that a domain change is applied to the
+// running scheduler without restarting the DNS updates, and that a global
+// change restarts them.
+func TestReload_Incremental(t *testing.T) {
+	m := newTestManager(t)
+	defer m.Stop()
+	m.Run()
+
+	engineCtx := m.engineCtx
+	oldHandler := m.handler
+
+	newConfig := *m.config
+	newConfig.Domains = []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}}}
+	m.Reload(&newConfig)
+
+	if m.engineCtx != engineCtx {
+		t.Error("expected the DNS updates to keep running after a domain change")
+	}
+	if m.handler == oldHandler {
+		t.Error("expected a new handler for the new configuration")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.scheduler.NextRuns()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, exists := m.scheduler.NextRuns()["Cloudflare/example.com"]; !exists {
+		t.Errorf("expected example.com to be scheduled, got %v", m.scheduler.NextRuns())
+	}
+
+	globalConfig := newConfig
+	globalConfig.Interval = 120
+	m.Reload(&globalConfig)
+
+	if m.engineCtx == engineCtx {
+		t.Error("expected the DNS updates to be restarted after a global change")
+	}
+	if engineCtx.Err() == nil {
+		t.Error("expected the previous DNS updates to be stopped")
+	}
+	if m.config != &globalConfig {
+		t.Error("expected the new configuration to be applied")
+	}
+}
+
+// TestReloadConfig checks the configuration file is validated before it
+// replaces the running configuration.
+func TestReloadConfig(t *testing.T) {
+	m := newTestManager(t)
+	defer m.Stop()
+
+	oldConfig := m.config
+	if err := m.ReloadConfig(); err == nil {
+		t.Error("expected the empty configuration file to be rejected")
+	}
+	if m.config != oldConfig {
+		t.Error("expected the previous configuration to be kept")
+	}
+
+	config := `{
+		"provider": "Cloudflare",
+		"email": "test@example.com",
+		"login_token": "test-token",
+		"interval": 60,
+		"ip_urls": ["http://127.0.0.1:1"],
+		"domains": [{"domain_name": "example.com", "sub_domains": ["www"]}]
+	}`
+	if err := os.WriteFile(m.configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ReloadConfig(); err != nil {
+		t.Fatalf("failed to reload the configuration: %s", err)
+	}
+	if len(m.config.Domains) != 1 || m.config.Domains[0].DomainName != "example.com" {
+		t.Errorf("expected the new configuration to be applied, got %+v", m.config.Domains)
+	}
+}
+
+// TestReload_WebPanelEdit checks that a provider edited through the web panel
+// is applied once the saved configuration file is reloaded: the panel edits a
+// copy, so the reload sees the change.
+func TestReload_WebPanelEdit(t *testing.T) {
+	m := newTestManager(t)
+	defer m.Stop()
+	m.config.IPUrls = []string{"http://127.0.0.1:1"}
+	m.config.Domains = []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}}}
+
+	app := fiber.New()
+	app.Put("/provider", controllers.NewController(m.config, m.configPath).UpdateProvider)
+
+	body := `{"provider": "Cloudflare", "email": "test@example.com", "login_token": "new-token"}`
+	req := httptest.NewRequest(http.MethodPut, "/provider", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	if m.config.LoginToken != "test-token" {
+		t.Error("expected the running configuration to be left untouched until the reload")
+	}
+
+	if err := m.ReloadConfig(); err != nil {
+		t.Fatalf("failed to reload the configuration: %s", err)
+	}
+	if m.config.LoginToken != "new-token" {
+		t.Errorf("expected the edited provider to be applied, got token %q", m.config.LoginToken)
+	}
+}
+
+// TestReload_AddDomain checks that the legacy provider is re-initialized with
+// the domains added by a reload, so that it can update their records.
+func TestReload_AddDomain(t *testing.T) {
+	m := newTestManager(t)
+	defer m.Stop()
+	m.config.IPUrls = []string{"http://127.0.0.1:1"}
+
+	created := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/zones", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"success": true, "result": [{"id": "zone1", "name": "b.com"}]}`))
+	})
+	mux.HandleFunc("/zones/zone1/dns_records", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			created++
+			_, _ = w.Write([]byte(`{"success": true, "result": {"id": "record1"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success": true, "result": [{"id": "record0", "name": "mail.b.com", "type": "A", "content": "192.0.2.2"}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	oneDomain := *m.config
+	oneDomain.Domains = []settings.Domain{{DomainName: "a.com", SubDomains: []string{"www"}}}
+	m.Reload(&oneDomain)
+
+	twoDomains := oneDomain
+	twoDomains.Domains = append(slices.Clone(oneDomain.Domains), settings.Domain{DomainName: "b.com", SubDomains: []string{"www"}})
+	m.Reload(&twoDomains)
+
+	dnsProvider, ok := m.provider.(*cloudflare.DNSProvider)
+	if !ok {
+		t.Fatalf("unexpected provider %T", m.provider)
+	}
+	dnsProvider.API = srv.URL
+
+	if err := dnsProvider.UpdateIP(context.Background(), "b.com", "www", "192.0.2.1"); err != nil {
+		t.Fatalf("failed to update the added domain: %s", err)
+	}
+	if created != 1 {
+		t.Errorf("expected the record of the added domain to be created, got %d creation(s)", created)
+	}
+}
@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// configuredRecord is a record managed by a configuration.
+type configuredRecord struct {
+	provider   string
+	domainName string
+	subDomain  string
+	recordType string
+}
+
+func (r configuredRecord) hostname() string {
+	return record.Hostname(r.domainName, r.subDomain)
+}
+
+// configuredRecords returns the records managed by a configuration.
+func configuredRecords(conf *settings.Settings) map[configuredRecord]bool {
+	records := map[configuredRecord]bool{}
+	for i := range conf.Domains {
+		domain := &conf.Domains[i]
+		for _, subDomain := range domain.SubDomains {
+			for _, ipType := range utils.GetIPTypes(conf.GetSubDomainIPType(domain, subDomain)) {
+				recordType := utils.IPTypeA
+				if ipType == utils.IPV6 {
+					recordType = utils.IPTypeAAAA
+				}
+				records[configuredRecord{conf.GetDomainProvider(domain), domain.DomainName, subDomain, recordType}] = true
+			}
+		}
+	}
+	return records
+}
+
+// removedRecords returns the records of the old configuration missing from the new one,
+// e.g. of a removed subdomain or domain, or of an IP family no longer used.
+func removedRecords(oldConf, newConf *settings.Settings) []configuredRecord {
+	newRecords := configuredRecords(newConf)
+
+	var removed []configuredRecord
+	for rec := range configuredRecords(oldConf) {
+		if !newRecords[rec] {
+			removed = append(removed, rec)
+		}
+	}
+	slices.SortFunc(removed, func(a, b configuredRecord) int {
+		return cmp.Or(
+			strings.Compare(a.provider, b.provider),
+			strings.Compare(a.hostname(), b.hostname()),
+			strings.Compare(a.recordType, b.recordType),
+		)
+	})
+	return removed
+}
+
+// pruneRecords deletes the records removed from the configuration through the
+// providers of the old configuration. Only the records managed by GoDNS are
+// deleted: the providers supporting the ownership check the records themselves,
+// the others only delete the records created by GoDNS according to the state.
+// A record which existed before GoDNS updated it is left alone.
+func (manager *DNSManager) pruneRecords(oldConfig *settings.Settings, oldProviders map[string]provider.IDNSProviderV2, oldProvider provider.IDNSProviderV2) {
+	kept := map[string]bool{}
+	for rec := range configuredRecords(manager.config) {
+		kept[rec.hostname()+"/"+rec.recordType] = true
+	}
+
+	for _, rec := range removedRecords(oldConfig, manager.config) {
+		hostname := rec.hostname()
+
+		dnsProvider := oldProvider
+		if oldConfig.IsMultiProvider() {
+			dnsProvider = oldProviders[rec.provider]
+		}
+		deleter, ok := dnsProvider.(provider.IRecordDeleter)
+		if !ok {
+			log.Warnf("Not pruning the %s record of %s: provider %s can't delete records", rec.recordType, hostname, rec.provider)
+			continue
+		}
+
+		previous, exists := manager.stateStore.Get(hostname, rec.recordType)
+		info, _ := registry.Get(oldConfig.GetProviderType(rec.provider))
+		if !info.Capabilities.Ownership && (!exists || !previous.Owned()) {
+			log.Infof("Not pruning the %s record of %s: it was neither created nor adopted by GoDNS", rec.recordType, hostname)
+			continue
+		}
+
+		if manager.config.DryRun {
+			log.Infof("[dry-run] Would delete the %s record of %s from %s", rec.recordType, hostname, rec.provider)
+			continue
+		}
+
+		ctx, cancel := manager.pruneContext()
+		err := deleter.DeleteRecord(ctx, rec.domainName, rec.subDomain, rec.recordType, previous.Value)
+		cancel()
+		if err != nil {
+			log.Errorf("Failed to prune the %s record of %s: %s", rec.recordType, hostname, err)
+			continue
+		}
+
+		log.Infof("Pruned the %s record of %s from %s", rec.recordType, hostname, rec.provider)
+		// the same record may still be managed through another provider
+		if !kept[hostname+"/"+rec.recordType] {
+			manager.stateStore.Delete(hostname, rec.recordType)
+		}
+	}
+}
+
+// pruneContext returns the context of a record deletion, bounded by the retry timeout.
+func (manager *DNSManager) pruneContext() (context.Context, context.CancelFunc) {
+	if timeout := manager.config.Retry.Timeout; timeout > 0 {
+		return context.WithTimeout(manager.ctx, time.Duration(timeout)*time.Second)
+	}
+	return context.WithCancel(manager.ctx)
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/state"
+)
+
+type fakeDeleter struct {
+	deleted []string
+}
+
+func (p *fakeDeleter) Init(_ *settings.Settings) {}
+
+func (p *fakeDeleter) UpdateIP(_ context.Context, _, _, _ string) error {
+	return nil
+}
+
+func (p *fakeDeleter) DeleteRecord(_ context.Context, domainName, subdomainName, recordType, value string) error {
+	p.deleted = append(p.deleted, record.Hostname(domainName, subdomainName)+"/"+recordType+"="+value)
+	return nil
+}
+
+// TestPruneRecords checks that only the removed records created or adopted by GoDNS are deleted.
+func TestPruneRecords(t *testing.T) {
+	oldConfig := &settings.Settings{
+		Providers: map[string]*settings.ProviderConfig{"fake": {}},
+		Domains: []settings.Domain{
+			{DomainName: "example.com", SubDomains: []string{"www", "api", "@"}, Provider: "fake"},
+			{DomainName: "example.org", SubDomains: []string{"www", "vpn"}, Provider: "fake"},
+		},
+	}
+	newConfig := &settings.Settings{
+		Providers: oldConfig.Providers,
+		Prune:     true,
+		Domains: []settings.Domain{
+			{DomainName: "example.com", SubDomains: []string{"www"}, Provider: "fake"},
+		},
+	}
+
+	store := state.NewStore()
+	store.RecordPush("www.example.com", "A", "1.2.3.4")
+	store.RecordCreated("www.example.com", "A")
+	store.RecordPush("api.example.com", "A", "1.2.3.4")
+	store.RecordCreated("api.example.com", "A")
+	// updated by GoDNS, but it existed before
+	store.RecordPush("www.example.org", "A", "1.2.3.4")
+	// existed before, and adopted
+	store.RecordPush("vpn.example.org", "A", "1.2.3.4")
+	store.RecordAdopted("vpn.example.org", "A")
+	// only confirmed by a DNS lookup, GoDNS never updated it
+	store.RecordSuccess("example.com", "A", "1.2.3.4")
+
+	deleter := &fakeDeleter{}
+	providers := map[string]provider.IDNSProviderV2{"fake": deleter}
+	m := &DNSManager{config: newConfig, stateStore: store, ctx: context.Background()}
+
+	m.config.DryRun = true
+	m.pruneRecords(oldConfig, providers, nil)
+	if len(deleter.deleted) != 0 {
+		t.Errorf("expected no deletion in dry run mode, got %v", deleter.deleted)
+	}
+
+	m.config.DryRun = false
+	m.pruneRecords(oldConfig, providers, nil)
+	if !slices.Equal(deleter.deleted, []string{"api.example.com/A=1.2.3.4", "vpn.example.org/A=1.2.3.4"}) {
+		t.Errorf("unexpected deleted records: %v", deleter.deleted)
+	}
+	if _, exists := store.Get("api.example.com", "A"); exists {
+		t.Error("expected the state of the deleted record to be forgotten")
+	}
+	if _, exists := store.Get("www.example.com", "A"); !exists {
+		t.Error("expected the state of the kept record to be kept")
+	}
+}
@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/TimothyYe/godns/internal/provider"
+	"github.com/TimothyYe/godns/internal/scheduler"
+	"github.com/TimothyYe/godns/internal/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reload applies a new configuration, restarting only what changed: the
+// added, removed and changed domains are updated in the scheduler, and the
+// changed providers are re-initialized. The DNS updates are only restarted
+// if a global setting changed, and the web panel if its settings changed.
+func (manager *DNSManager) Reload(newConfig *settings.Settings) {
+	manager.restartMu.Lock()
+	defer manager.restartMu.Unlock()
+
+	diff := settings.Compare(manager.config, newConfig)
+	if diff.IsEmpty() {
+		log.Info("Configuration unchanged, nothing to reload")
+		return
+	}
+
+	oldConfig := manager.config
+	oldProviders, oldProvider := manager.providers, manager.provider
+	manager.config = newConfig
+
+	if diff.Global {
+		log.Info("Global settings changed, restarting the DNS updates...")
+		manager.stopEngine()
+		if err := manager.initEngine(); err != nil {
+			log.Fatalf("Error during DNS manager reloading: %s", err)
+		}
+		manager.startEngine()
+	} else if err := manager.applyDomainChanges(oldConfig, diff); err != nil {
+		log.Errorf("Failed to reload the configuration, keeping the previous one: %s", err)
+		manager.config = oldConfig
+		return
+	}
+
+	if manager.config.Prune {
+		manager.pruneRecords(oldConfig, oldProviders, oldProvider)
+	}
+
+	if manager.config.RunOnce {
+		return
+	}
+
+	if diff.WebPanel {
+		log.Info("Web panel settings changed, restarting the web panel...")
+		if manager.server != nil {
+			manager.server.Stop()
+			manager.server = nil
+		}
+		manager.startServer()
+	} else if manager.server != nil {
+		manager.server.SetConfig(manager.config).SetScheduler(manager.scheduler)
+	}
+
+	log.Info("Configuration reloaded successfully")
+}
+
+// applyDomainChanges re-initializes the changed providers and the providers
+// of the changed domains, and replaces the scheduler jobs of the domains which
+// changed or use a changed provider.
+func (manager *DNSManager) applyDomainChanges(oldConfig *settings.Settings, diff *settings.Diff) error {
+	providers := maps.Clone(manager.providers)
+	legacyProvider := manager.provider
+	for _, name := range manager.reloadedProviders(oldConfig, diff) {
+		if _, exists := manager.config.Providers[name]; !exists && name != manager.config.Provider {
+			delete(providers, name)
+			continue
+		}
+
+		dnsProvider, err := provider.GetProviderByName(manager.config, name)
+		if err != nil {
+			return err
+		}
+		if manager.config.IsMultiProvider() {
+			providers[name] = dnsProvider
+		} else {
+			legacyProvider = dnsProvider
+		}
+		log.Infof("Re-initialized provider: %s", name)
+	}
+
+	// the domains of a changed provider are reloaded as well
+	removed := slices.Concat(diff.RemovedDomains, diff.ChangedDomains)
+	for i := range oldConfig.Domains {
+		if slices.Contains(diff.Providers, oldConfig.GetDomainProvider(&oldConfig.Domains[i])) {
+			removed = append(removed, oldConfig.DomainKey(&oldConfig.Domains[i]))
+		}
+	}
+
+	// the domains left unchanged keep running with the previous handler, which
+	// holds the same settings for them
+	domainHandler := manager.newHandler(providers, legacyProvider)
+	var added []scheduler.Job
+	for i := range manager.config.Domains {
+		domain := &manager.config.Domains[i]
+		key := manager.config.DomainKey(domain)
+		if !slices.Contains(diff.AddedDomains, key) && !slices.Contains(diff.ChangedDomains, key) &&
+			!slices.Contains(diff.Providers, manager.config.GetDomainProvider(domain)) {
+			continue
+		}
+
+		job, err := manager.domainJob(domainHandler, domain)
+		if err != nil {
+			return fmt.Errorf("failed to reload domain %s: %w", domain.DomainName, err)
+		}
+		added = append(added, job)
+	}
+
+	manager.providers = providers
+	manager.provider = legacyProvider
+	manager.handler = domainHandler
+
+	if manager.scheduler == nil {
+		return nil
+	}
+
+	// the jobs of the domains still configured are replaced, so that a run in
+	// progress with the previous settings isn't overlapped by the new one
+	for _, key := range removed {
+		if !slices.ContainsFunc(added, func(job scheduler.Job) bool { return job.ID == key }) {
+			manager.scheduler.Remove(key)
+		}
+	}
+	for _, job := range added {
+		log.Infof("Reloading domain: %s", job.ID)
+		manager.scheduler.Replace(job)
+	}
+
+	return nil
+}
+
+// reloadedProviders returns the names of the changed providers and of the
+// providers of the added, changed or removed domains: they hold the settings
+// of their domains, e.g. the list of the domains or their record options.
+func (manager *DNSManager) reloadedProviders(oldConfig *settings.Settings, diff *settings.Diff) []string {
+	names := slices.Clone(diff.Providers)
+	for _, config := range []*settings.Settings{oldConfig, manager.config} {
+		for i := range config.Domains {
+			domain := &config.Domains[i]
+			key := config.DomainKey(domain)
+			if !slices.Contains(diff.AddedDomains, key) && !slices.Contains(diff.ChangedDomains, key) &&
+				!slices.Contains(diff.RemovedDomains, key) {
+				continue
+			}
+
+			if name := config.GetDomainProvider(domain); !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
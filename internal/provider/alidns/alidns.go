@@ -1,6 +1,7 @@
 package alidns
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
@@ -14,7 +15,6 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/TimothyYe/godns/internal/utils"
@@ -34,9 +34,6 @@ var (
 		"SignatureVersion": "1.0",
 		"SignatureNonce":   "",
 	}
-	instance *AliDNS
-	once     sync.Once
-
 	// httpClient is shared across alidns GET helpers. Bounded timeout
 	// prevents a slow upstream from blocking the update loop indefinitely
 	// (the previous `http.Get` used the default client with no timeout).
@@ -47,7 +44,8 @@ var (
 type AliDNS struct {
 	AccessKeyID     string
 	AccessKeySecret string
-	IPType          string
+	// API is the address of the AliDNS API.
+	API string
 }
 
 type domainRecordsResp struct {
@@ -76,12 +74,20 @@ type DomainRecord struct {
 	Locked     bool
 }
 
-func getHTTPBody(url string) ([]byte, error) {
-	resp, err := httpClient.Get(url)
+func getHTTPBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return nil, err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if resp.StatusCode == http.StatusOK {
@@ -90,48 +96,79 @@ func getHTTPBody(url string) ([]byte, error) {
 	return nil, fmt.Errorf("status %d, Error:%s", resp.StatusCode, body)
 }
 
-// NewAliDNS function creates instance of AliDNS and return.
-func NewAliDNS(key, secret, ipType string) *AliDNS {
-	once.Do(func() {
-		instance = &AliDNS{
-			AccessKeyID:     key,
-			AccessKeySecret: secret,
-			IPType:          ipType,
-		}
-	})
-	return instance
+// NewAliDNS creates a client of AliDNS with the given access key.
+func NewAliDNS(key, secret string) *AliDNS {
+	return &AliDNS{
+		AccessKeyID:     key,
+		AccessKeySecret: secret,
+		API:             baseURL,
+	}
 }
 
-// GetDomainRecords gets all the domain records according to input subdomain key.
-func (d *AliDNS) GetDomainRecords(domain, rr string) []DomainRecord {
-	resp := &domainRecordsResp{}
-	params := map[string]string{
+// GetDomainRecords gets all the domain records of the given type (A or AAAA) according to input subdomain key.
+func (d *AliDNS) GetDomainRecords(ctx context.Context, domain, rr, recordType string) []DomainRecord {
+	records, err := d.GetSubDomainRecords(ctx, domain, rr, recordType)
+	if err != nil {
+		fmt.Printf("GetDomainRecords error.%+v\n", err)
+		return nil
+	}
+	return records
+}
+
+// GetSubDomainRecords gets the records of the given type of a subdomain.
+func (d *AliDNS) GetSubDomainRecords(ctx context.Context, domain, rr, recordType string) ([]DomainRecord, error) {
+	resp, err := d.describeRecords(ctx, map[string]string{
 		"Action":    "DescribeSubDomainRecords",
 		"SubDomain": fmt.Sprintf("%s.%s", rr, domain),
+		"Type":      recordType,
+	})
+	if err != nil {
+		return nil, err
 	}
+	return resp.DomainRecords.Record, nil
+}
 
-	if d.IPType == "" || strings.ToUpper(d.IPType) == utils.IPV4 {
-		params["Type"] = utils.IPTypeA
-	} else if strings.ToUpper(d.IPType) == utils.IPV6 {
-		params["Type"] = utils.IPTypeAAAA
+// ListDomainRecords gets all the records of a domain.
+func (d *AliDNS) ListDomainRecords(ctx context.Context, domain string) ([]DomainRecord, error) {
+	var records []DomainRecord
+	for page := 1; ; page++ {
+		resp, err := d.describeRecords(ctx, map[string]string{
+			"Action":     "DescribeDomainRecords",
+			"DomainName": domain,
+			"PageNumber": strconv.Itoa(page),
+			"PageSize":   "500",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, resp.DomainRecords.Record...)
+		if len(resp.DomainRecords.Record) == 0 || len(records) >= resp.TotalCount {
+			return records, nil
+		}
 	}
+}
 
+func (d *AliDNS) describeRecords(ctx context.Context, params map[string]string) (*domainRecordsResp, error) {
 	urlPath := d.genRequestURL(params)
-	body, err := getHTTPBody(urlPath)
+	if urlPath == "" {
+		return nil, errors.New("failed to generate request URL")
+	}
+
+	body, err := getHTTPBody(ctx, urlPath)
 	if err != nil {
-		fmt.Printf("GetDomainRecords error.%+v\n", err)
-	} else {
-		if err := json.Unmarshal(body, resp); err != nil {
-			fmt.Printf("GetDomainRecords error. %+v\n", err)
-			return nil
-		}
-		return resp.DomainRecords.Record
+		return nil, err
 	}
-	return nil
+
+	resp := &domainRecordsResp{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // UpdateDomainRecord updates domain record.
-func (d *AliDNS) UpdateDomainRecord(r DomainRecord) error {
+func (d *AliDNS) UpdateDomainRecord(ctx context.Context, r DomainRecord) error {
 	params := map[string]string{
 		"Action":   "UpdateDomainRecord",
 		"RecordId": r.RecordID,
@@ -139,19 +176,14 @@ func (d *AliDNS) UpdateDomainRecord(r DomainRecord) error {
 		"Value":    r.Value,
 		"TTL":      strconv.Itoa(r.TTL),
 		"Line":     r.Line,
-	}
-
-	if d.IPType == "" || strings.ToUpper(d.IPType) == utils.IPV4 {
-		params["Type"] = utils.IPTypeA
-	} else if strings.ToUpper(d.IPType) == utils.IPV6 {
-		params["Type"] = utils.IPTypeAAAA
+		"Type":     utils.GetRecordType(r.Value),
 	}
 
 	urlPath := d.genRequestURL(params)
 	if urlPath == "" {
 		return errors.New("failed to generate request URL")
 	}
-	_, err := getHTTPBody(urlPath)
+	_, err := getHTTPBody(ctx, urlPath)
 	if err != nil {
 		fmt.Printf("UpdateDomainRecord error.%+v\n", err)
 	}
@@ -173,20 +205,29 @@ func (d *AliDNS) genRequestURL(params map[string]string) string {
 	ps["Timestamp"] = now.Format("2006-01-02T15:04:05Z")
 
 	for k, v := range ps {
-		pArr = append(pArr, fmt.Sprintf("%s=%s", k, v))
+		pArr = append(pArr, percentEncode(k)+"="+percentEncode(v))
 	}
 	sort.Strings(pArr)
-	path := strings.Join(pArr, "&")
+	query := strings.Join(pArr, "&")
 
-	s := "GET&%2F&" + url.QueryEscape(path)
-	s = strings.Replace(s, "%3A", "%253A", -1)
-	s = strings.Replace(s, "%40", "%2540", -1)
-	s = strings.Replace(s, "%2A", "%252A", -1)
-	mac := hmac.New(sha1.New, []byte(d.AccessKeySecret+"&"))
+	sign := signQuery(query, d.AccessKeySecret)
+	if sign == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s?%s&Signature=%s", d.API, query, percentEncode(sign))
+}
 
-	if _, err := mac.Write([]byte(s)); err != nil {
+// signQuery returns the signature of a GET request with the given canonical query.
+func signQuery(query, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	if _, err := mac.Write([]byte("GET&%2F&" + percentEncode(query))); err != nil {
 		return ""
 	}
-	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	return fmt.Sprintf("%s?%s&Signature=%s", baseURL, path, url.QueryEscape(sign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode escapes a parameter as required by the API signature, e.g. the
+// wildcard of *.home is sent as %2A and a space as %20.
+func percentEncode(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
 }
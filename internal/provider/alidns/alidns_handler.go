@@ -1,27 +1,45 @@
 package alidns
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "AliDNS"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		Email:        true,
+		Password:     true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 // DNSProvider struct.
 type DNSProvider struct {
-	aliDNS *AliDNS
+	configuration *settings.Settings
+	aliDNS        *AliDNS
 }
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
+	provider.configuration = conf
 	provider.aliDNS = NewAliDNS(
 		conf.Email,
-		conf.Password,
-		conf.IPType)
+		conf.Password)
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	log.Infof("%s.%s - Start to update record IP...", subdomainName, domainName)
-	records := provider.aliDNS.GetDomainRecords(domainName, subdomainName)
+	records := provider.aliDNS.GetDomainRecords(ctx, domainName, subdomainName, utils.GetRecordType(ip))
 	if len(records) == 0 {
 		log.Errorf("Cannot get subdomain [%s] from AliDNS.", subdomainName)
 		return fmt.Errorf("cannot get subdomain [%s] from AliDNS", subdomainName)
@@ -29,8 +47,11 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 	if records[0].Value != ip {
 		records[0].Value = ip
-		if err := provider.aliDNS.UpdateDomainRecord(records[0]); err != nil {
-			return fmt.Errorf("failed to update IP for subdomain: %s", subdomainName)
+		if ttl := provider.configuration.GetRecordOptions(domainName, subdomainName).TTL; ttl > 0 {
+			records[0].TTL = ttl
+		}
+		if err := provider.aliDNS.UpdateDomainRecord(ctx, records[0]); err != nil {
+			return fmt.Errorf("failed to update IP for subdomain %s: %w", subdomainName, err)
 		}
 
 		log.Infof("IP updated for subdomain: %s", subdomainName)
@@ -40,3 +61,35 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 	return nil
 }
+
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	records, err := provider.aliDNS.ListDomainRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	return toRecords(domainName, records), nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	records, err := provider.aliDNS.GetSubDomainRecords(ctx, domainName, subdomainName, recordType)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(toRecords(domainName, records), record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+func toRecords(domainName string, records []DomainRecord) []record.Record {
+	result := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		result = append(result, record.Record{
+			Name:  record.Hostname(domainName, rec.RR),
+			Type:  rec.Type,
+			Value: rec.Value,
+			TTL:   rec.TTL,
+			ID:    rec.RecordID,
+		})
+	}
+	return result
+}
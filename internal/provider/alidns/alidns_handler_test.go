@@ -0,0 +1,55 @@
+package alidns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/utils"
+)
+
+// Each provider instance keeps the credentials of its own account.
+func TestInitSeparateAccounts(t *testing.T) {
+	home := &DNSProvider{}
+	home.Init(&settings.Settings{Email: "home-key", Password: "home-secret"})
+	work := &DNSProvider{}
+	work.Init(&settings.Settings{Email: "work-key", Password: "work-secret"})
+
+	if home.aliDNS.AccessKeyID != "home-key" || home.aliDNS.AccessKeySecret != "home-secret" {
+		t.Errorf("unexpected credentials of the first account: %+v", home.aliDNS)
+	}
+	if work.aliDNS.AccessKeyID != "work-key" || work.aliDNS.AccessKeySecret != "work-secret" {
+		t.Errorf("unexpected credentials of the second account: %+v", work.aliDNS)
+	}
+}
+
+// A rate limited update is reported to the retry policy with its Retry-After delay.
+func TestUpdateIPRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("Action") {
+		case "DescribeSubDomainRecords":
+			_, _ = w.Write([]byte(`{"TotalCount":1,"DomainRecords":{"Record":[{"RecordId":"1","RR":"www","Type":"A","Value":"198.51.100.4","TTL":600}]}}`))
+		default:
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	provider := &DNSProvider{}
+	provider.Init(&settings.Settings{Email: "key", Password: "secret"})
+	provider.aliDNS.API = srv.URL + "/"
+
+	err := provider.UpdateIP(context.Background(), "example.com", "www", "192.0.2.10")
+	var retryAfterErr *utils.RetryAfterError
+	if !errors.As(err, &retryAfterErr) {
+		t.Fatalf("expected a RetryAfterError, got %v", err)
+	}
+	if retryAfterErr.RetryAfter != 30*time.Second {
+		t.Errorf("expected a retry after 30s, got %s", retryAfterErr.RetryAfter)
+	}
+}
@@ -0,0 +1,28 @@
+package alidns
+
+import "testing"
+
+func TestPercentEncode(t *testing.T) {
+	tests := map[string]string{
+		"*.home.example.com":   "%2A.home.example.com",
+		"2016-02-23T12:46:24Z": "2016-02-23T12%3A46%3A24Z",
+		"a b~c":                "a%20b~c",
+	}
+
+	for value, want := range tests {
+		if got := percentEncode(value); got != want {
+			t.Errorf("percentEncode(%q): expected %s, got %s", value, want, got)
+		}
+	}
+}
+
+// The example of the signature documentation of the Alibaba Cloud API.
+func TestSignQuery(t *testing.T) {
+	query := "AccessKeyId=testid&Action=DescribeRegions&Format=XML&SignatureMethod=HMAC-SHA1" +
+		"&SignatureNonce=3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf&SignatureVersion=1.0" +
+		"&Timestamp=2016-02-23T12%3A46%3A24Z&Version=2014-05-26"
+
+	if sign := signQuery(query, "testsecret"); sign != "OLeaidS1JvxuMvnyHOwuJ+uX5qY=" {
+		t.Errorf("unexpected signature: %s", sign)
+	}
+}
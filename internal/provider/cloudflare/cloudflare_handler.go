@@ -2,17 +2,46 @@ package cloudflare
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Cloudflare"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, TTL: true, Proxied: true, Comment: true, Ownership: true, Zone: true, Create: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+		Validate: func(credentials registry.Credentials) error {
+			// the API token replaces the global API key
+			if credentials.LoginToken != "" {
+				return nil
+			}
+			if credentials.Email == "" {
+				return errors.New("email cannot be empty")
+			}
+			if credentials.Password == "" {
+				return errors.New("password cannot be empty")
+			}
+			return nil
+		},
+	})
+}
+
 const (
 	// URL is the endpoint for the Cloudflare API.
 	URL = "https://api.cloudflare.com/client/v4"
@@ -38,13 +67,15 @@ type DNSRecordUpdateResponse struct {
 
 // DNSRecord for Cloudflare API.
 type DNSRecord struct {
-	ID      string `json:"id"`
-	IP      string `json:"content"`
-	Name    string `json:"name"`
-	Proxied bool   `json:"proxied"`
-	Type    string `json:"type"`
-	ZoneID  string `json:"zone_id"`
-	TTL     int32  `json:"ttl"`
+	ID      string   `json:"id"`
+	IP      string   `json:"content"`
+	Name    string   `json:"name"`
+	Proxied bool     `json:"proxied"`
+	Type    string   `json:"type"`
+	ZoneID  string   `json:"zone_id"`
+	TTL     int32    `json:"ttl"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
 }
 
 // SetIP updates DNSRecord.IP.
@@ -70,14 +101,25 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.API = URL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	log.Infof("Checking IP for domain %s.%s", subdomainName, domainName)
-	zoneID := provider.getZone(domainName)
+	domain := provider.getCurrentDomain(domainName)
+	if domain == nil {
+		return fmt.Errorf("domain %s is not configured for this provider", domainName)
+	}
+
+	zoneID := provider.getZone(ctx, domainName)
 	if zoneID != "" {
-		records := provider.getDNSRecords(zoneID)
+		records, err := provider.getDNSRecords(ctx, zoneID, utils.GetRecordType(ip))
+		if err != nil {
+			return err
+		}
+
 		matched := false
 		var matchingRecords []DNSRecord
 		var fullDomainName string
+		foreignRecords := 0
+		options := provider.configuration.GetRecordOptions(domainName, subdomainName)
 
 		// Determine the full domain name we're looking for
 		if subdomainName == utils.RootDomain {
@@ -89,20 +131,31 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 		// Collect all matching records for this subdomain
 		for _, rec := range records {
 			rec := rec
-			if !recordTracked(provider.getCurrentDomain(domainName), &rec) {
+			if !recordTracked(domain, &rec) {
 				log.Debug("Skipping record:", rec.Name)
 				continue
 			}
 
 			if rec.Name == fullDomainName {
+				// the records managed by hand are left alone, unless adopted
+				if !options.Adopt && !record.IsOwned(rec.Comment) {
+					log.Debugf("Skipping record not managed by GoDNS: %s (ID: %s)", rec.Name, rec.ID)
+					foreignRecords++
+					continue
+				}
 				matchingRecords = append(matchingRecords, rec)
 			}
 		}
 
+		if len(matchingRecords) == 0 && foreignRecords > 0 {
+			return fmt.Errorf("%s: %w, set adopt to take it over", fullDomainName, utils.ErrNotOwned)
+		}
+
 		// Process matching records
 		if len(matchingRecords) > 0 {
 			matched = true
 			updatedRecordID := ""
+			var updateErr error
 
 			// Update or keep the first record with correct IP
 			for i, rec := range matchingRecords {
@@ -114,33 +167,48 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 					log.Infof("IP mismatch: Current(%+v) vs Cloudflare(%+v)", ip, rec.IP)
 					if i == 0 {
 						// Update the first record
-						provider.updateRecord(rec, ip)
+						applyRecordOptions(&rec, options)
+						rec.Comment = record.MarkOwned(rec.Comment)
+						if _, err := provider.updateRecord(ctx, rec, ip); err != nil {
+							updateErr = err
+						}
 						updatedRecordID = rec.ID
 					}
 				} else {
 					log.Infof("Record OK: %+v - %+v", rec.Name, rec.IP)
 					if updatedRecordID == "" {
 						updatedRecordID = rec.ID
+						// mark the adopted record
+						if !record.IsOwned(rec.Comment) {
+							rec.Comment = record.MarkOwned(rec.Comment)
+							if _, err := provider.updateRecord(ctx, rec, ip); err != nil {
+								updateErr = err
+							}
+						}
 					}
 				}
 			}
 
-			// Delete all duplicate records except the one we updated/kept
+			// Delete all the duplicate records owned by GoDNS except the one we updated/kept
 			for _, rec := range matchingRecords {
 				if rec.ID != updatedRecordID {
 					log.Infof("Deleting duplicate record: %+v (ID: %s)", rec.Name, rec.ID)
-					if err := provider.deleteRecord(zoneID, rec.ID); err != nil {
+					if err := provider.deleteRecord(ctx, zoneID, rec.ID); err != nil {
 						log.Errorf("Failed to delete duplicate record %s: %v", rec.ID, err)
 					} else {
 						log.Infof("Duplicate record deleted: %+v", rec.Name)
 					}
 				}
 			}
+
+			if updateErr != nil {
+				return updateErr
+			}
 		}
 
 		if !matched {
 			log.Debugf("Record %s not found, will create it.", subdomainName)
-			if err := provider.createRecord(zoneID, domainName, subdomainName, ip); err != nil {
+			if err := provider.createRecord(ctx, zoneID, domainName, subdomainName, ip); err != nil {
 				return err
 			}
 			log.Infof("Record [%s] created with IP address: %s", subdomainName, ip)
@@ -166,6 +234,10 @@ func (provider *DNSProvider) getCurrentDomain(domainName string) *settings.Domai
 
 // Check if record is present in domain conf.
 func recordTracked(domain *settings.Domain, record *DNSRecord) bool {
+	if domain == nil {
+		return false
+	}
+
 	for _, subDomain := range domain.SubDomains {
 		sd := fmt.Sprintf("%s.%s", subDomain, domain.DomainName)
 		if record.Name == sd {
@@ -179,13 +251,13 @@ func recordTracked(domain *settings.Domain, record *DNSRecord) bool {
 }
 
 // Create a new request with auth in place and optional proxy.
-func (provider *DNSProvider) newRequest(method, url string, body io.Reader) (*http.Request, *http.Client, error) {
+func (provider *DNSProvider) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, *http.Client, error) {
 	client := utils.GetHTTPClient(provider.configuration)
 	if client == nil {
 		log.Info("cannot create HTTP client")
 	}
 
-	req, err := http.NewRequest(method, provider.API+url, body)
+	req, err := http.NewRequestWithContext(ctx, method, provider.API+url, body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -202,10 +274,10 @@ func (provider *DNSProvider) newRequest(method, url string, body io.Reader) (*ht
 }
 
 // Find the correct zone via domain name.
-func (provider *DNSProvider) getZone(domain string) string {
+func (provider *DNSProvider) getZone(ctx context.Context, domain string) string {
 	var z ZoneResponse
 
-	req, client, err := provider.newRequest("GET", fmt.Sprintf("/zones?name=%s", domain), nil)
+	req, client, err := provider.newRequest(ctx, "GET", fmt.Sprintf("/zones?name=%s", domain), nil)
 	if err != nil {
 		log.Errorf("Failed to build request: %+v", err)
 		return ""
@@ -241,69 +313,111 @@ func (provider *DNSProvider) getZone(domain string) string {
 	return ""
 }
 
-// Get all DNS A records for a zone.
-func (provider *DNSProvider) getDNSRecords(zoneID string) []DNSRecord {
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	zoneID := provider.getZone(ctx, domainName)
+	if zoneID == "" {
+		return nil, fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	records, err := provider.getDNSRecords(ctx, zoneID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		result = append(result, record.Record{Name: rec.Name, Type: rec.Type, Value: rec.IP, TTL: int(rec.TTL), ID: rec.ID})
+	}
+	return result, nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	records, err := provider.GetRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+// DeleteRecord deletes the records of the subdomain with the given type which
+// are managed by GoDNS, the records managed by hand are left alone.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domainName, subdomainName, recordType, _ string) error {
+	zoneID := provider.getZone(ctx, domainName)
+	if zoneID == "" {
+		return fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	records, err := provider.getDNSRecords(ctx, zoneID, recordType)
+	if err != nil {
+		return err
+	}
+
+	hostname := record.Hostname(domainName, subdomainName)
+	for _, rec := range records {
+		if rec.Name != hostname {
+			continue
+		}
+		if !record.IsOwned(rec.Comment) {
+			log.Infof("Keeping record not managed by GoDNS: %s (ID: %s)", rec.Name, rec.ID)
+			continue
+		}
+
+		if err := provider.deleteRecord(ctx, zoneID, rec.ID); err != nil {
+			return err
+		}
+		log.Infof("Record deleted: %s (ID: %s)", rec.Name, rec.ID)
+	}
 
-	var empty []DNSRecord
+	return nil
+}
+
+// Get all DNS records of the given type (A or AAAA) for a zone, or of any type if it is empty.
+func (provider *DNSProvider) getDNSRecords(ctx context.Context, zoneID, recordType string) ([]DNSRecord, error) {
 	var r DNSRecordResponse
-	var recordType string
 
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		recordType = utils.IPTypeA
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		recordType = utils.IPTypeAAAA
+	url := fmt.Sprintf("/zones/%s/dns_records?page=1&per_page=500", zoneID)
+	if recordType != "" {
+		log.Infof("Querying records with type: %s", recordType)
+		url += "&type=" + recordType
 	}
 
-	log.Infof("Querying records with type: %s", recordType)
-	req, client, err := provider.newRequest("GET", fmt.Sprintf("/zones/"+zoneID+"/dns_records?type=%s&page=1&per_page=500", recordType), nil)
+	req, client, err := provider.newRequest(ctx, "GET", url, nil)
 	if err != nil {
-		log.Errorf("Failed to build request: %+v", err)
-		return empty
+		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error("Request error:", err)
-		return empty
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("Failed to read response body: %+v", err)
-		return empty
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		log.Infof("Decoder error: %+v", err)
 		log.Debugf("Response body: %+v", string(body))
-		return empty
+		return nil, fmt.Errorf("failed to decode the records: %w", err)
 	}
 	if !r.Success {
-		log.Infof("Response failed: %+v", string(body))
-		return empty
-
+		return nil, fmt.Errorf("failed to get the records: %s", string(body))
 	}
-	return r.Records
+	return r.Records, nil
 }
 
-func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string) error {
-	var recordType string
-
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		recordType = utils.IPTypeA
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		recordType = utils.IPTypeAAAA
-	}
-
+func (provider *DNSProvider) createRecord(ctx context.Context, zoneID, domain, subDomain, ip string) error {
 	newRecord := DNSRecord{
-		Type: recordType,
-		IP:   ip,
-		TTL:  1,
-	}
-
-	if provider.configuration.Proxied {
-		newRecord.Proxied = true
+		Type:    utils.GetRecordType(ip),
+		IP:      ip,
+		TTL:     1,
+		Proxied: provider.configuration.Proxied,
 	}
+	applyRecordOptions(&newRecord, provider.configuration.GetRecordOptions(domain, subDomain))
+	newRecord.Comment = record.MarkOwned(newRecord.Comment)
 
 	if subDomain == utils.RootDomain {
 		newRecord.Name = utils.RootDomain
@@ -317,7 +431,7 @@ func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string)
 		return err
 	}
 
-	req, client, err := provider.newRequest("POST", fmt.Sprintf("/zones/%s/dns_records", zoneID), bytes.NewBuffer(content))
+	req, client, err := provider.newRequest(ctx, "POST", fmt.Sprintf("/zones/%s/dns_records", zoneID), bytes.NewBuffer(content))
 	if err != nil {
 		log.Errorf("Failed to build request: %+v", err)
 		return err
@@ -329,6 +443,10 @@ func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string)
 	}
 
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorf("Failed to read request body: %+v", err)
@@ -350,52 +468,75 @@ func (provider *DNSProvider) createRecord(zoneID, domain, subDomain, ip string)
 	return nil
 }
 
+// applyRecordOptions sets the configured options of a record, leaving the others untouched.
+// The tags option is a comma-separated list of tags.
+func applyRecordOptions(rec *DNSRecord, options settings.RecordOptions) {
+	if options.TTL > 0 {
+		rec.TTL = int32(options.TTL)
+	}
+	if options.Proxied != nil {
+		rec.Proxied = *options.Proxied
+	}
+	if options.Comment != "" {
+		rec.Comment = options.Comment
+	}
+	if tags := options.Options["tags"]; tags != "" {
+		rec.Tags = nil
+		for _, tag := range strings.Split(tags, ",") {
+			rec.Tags = append(rec.Tags, strings.TrimSpace(tag))
+		}
+	}
+}
+
 // Update DNS A Record with new IP.
-func (provider *DNSProvider) updateRecord(record DNSRecord, newIP string) string {
+func (provider *DNSProvider) updateRecord(ctx context.Context, record DNSRecord, newIP string) (string, error) {
 
 	var r DNSRecordUpdateResponse
 	record.SetIP(newIP)
-	var lastIP string
 
 	j, _ := json.Marshal(record)
-	req, client, err := provider.newRequest("PUT",
+	req, client, err := provider.newRequest(ctx, "PUT",
 		"/zones/"+record.ZoneID+"/dns_records/"+record.ID,
 		bytes.NewBuffer(j),
 	)
 	if err != nil {
 		log.Errorf("Failed to build request: %+v", err)
-		return ""
+		return "", err
 	}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error("Request error:", err)
-		return ""
+		return "", err
 	}
 
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return "", err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorf("Failed to read response body: %+v", err)
-		return ""
+		return "", err
 	}
 	err = json.Unmarshal(body, &r)
 	if err != nil {
 		log.Errorf("Decoder error: %+v", err)
 		log.Debugf("Response body: %+v", string(body))
-		return ""
+		return "", err
 	}
 	if !r.Success {
 		log.Infof("Response failed: %+v", string(body))
-	} else {
-		log.Infof("Record updated: %+v - %+v", record.Name, record.IP)
-		lastIP = record.IP
+		return "", fmt.Errorf("failed to update record: %+v", string(body))
 	}
-	return lastIP
+
+	log.Infof("Record updated: %+v - %+v", record.Name, record.IP)
+	return record.IP, nil
 }
 
 // Delete a DNS record.
-func (provider *DNSProvider) deleteRecord(zoneID, recordID string) error {
-	req, client, err := provider.newRequest("DELETE",
+func (provider *DNSProvider) deleteRecord(ctx context.Context, zoneID, recordID string) error {
+	req, client, err := provider.newRequest(ctx, "DELETE",
 		"/zones/"+zoneID+"/dns_records/"+recordID,
 		nil,
 	)
@@ -164,4 +164,25 @@ func TestRecordTracked(t *testing.T) {
 			t.Logf("Record founded: %+v", rec.Name)
 		}
 	}
+
+	if recordTracked(nil, &resp.Records[0]) {
+		t.Error("expected no record to be tracked for a domain which isn't configured")
+	}
+}
+
+func TestApplyRecordOptions(t *testing.T) {
+	proxied := false
+	rec := DNSRecord{TTL: 1, Proxied: true, Comment: "existing"}
+	applyRecordOptions(&rec, settings.RecordOptions{
+		TTL:     300,
+		Proxied: &proxied,
+		Options: map[string]string{"tags": "home, ddns"},
+	})
+
+	if rec.TTL != 300 || rec.Proxied || rec.Comment != "existing" {
+		t.Errorf("unexpected record options: %+v", rec)
+	}
+	if len(rec.Tags) != 2 || rec.Tags[0] != "home" || rec.Tags[1] != "ddns" {
+		t.Errorf("unexpected tags: %v", rec.Tags)
+	}
 }
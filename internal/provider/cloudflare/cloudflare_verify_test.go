@@ -1,13 +1,18 @@
 package cloudflare
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sync"
 	"testing"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
 	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/TimothyYe/godns/internal/utils"
 )
 
 type apiLog struct {
@@ -70,19 +75,20 @@ func newTestProvider(apiURL string) *DNSProvider {
 }
 
 // Scenario from issue #268: flapping IPv6 left multiple AAAA records behind.
+// The records were created by GoDNS, so they hold the owner marker.
 func TestDedupMultipleStaleRecords(t *testing.T) {
 	calls := &apiLog{}
 	records := []DNSRecord{
-		{ID: "rec1", Name: "sub.example.com", IP: "fd00::old1", Type: "AAAA"},
-		{ID: "rec2", Name: "sub.example.com", IP: "fd00::current", Type: "AAAA"},
-		{ID: "rec3", Name: "sub.example.com", IP: "fd00::old2", Type: "AAAA"},
+		{ID: "rec1", Name: "sub.example.com", IP: "fd00::old1", Type: "AAAA", Comment: record.OwnerMarker},
+		{ID: "rec2", Name: "sub.example.com", IP: "fd00::current", Type: "AAAA", Comment: record.OwnerMarker},
+		{ID: "rec3", Name: "sub.example.com", IP: "fd00::old2", Type: "AAAA", Comment: record.OwnerMarker},
 		{ID: "rec9", Name: "other.example.com", IP: "fd00::other", Type: "AAAA"}, // untracked
 	}
 	srv := newMockCloudflare(t, records, calls)
 	defer srv.Close()
 
 	provider := newTestProvider(srv.URL)
-	if err := provider.UpdateIP("example.com", "sub", "fd00::current"); err != nil {
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
 		t.Fatalf("UpdateIP failed: %v", err)
 	}
 
@@ -109,13 +115,13 @@ func TestDedupMultipleStaleRecords(t *testing.T) {
 func TestSingleRecordUpToDate(t *testing.T) {
 	calls := &apiLog{}
 	records := []DNSRecord{
-		{ID: "rec1", Name: "sub.example.com", IP: "fd00::current", Type: "AAAA"},
+		{ID: "rec1", Name: "sub.example.com", IP: "fd00::current", Type: "AAAA", Comment: record.OwnerMarker},
 	}
 	srv := newMockCloudflare(t, records, calls)
 	defer srv.Close()
 
 	provider := newTestProvider(srv.URL)
-	if err := provider.UpdateIP("example.com", "sub", "fd00::current"); err != nil {
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
 		t.Fatalf("UpdateIP failed: %v", err)
 	}
 
@@ -129,14 +135,14 @@ func TestSingleRecordUpToDate(t *testing.T) {
 func TestRootDomainDedup(t *testing.T) {
 	calls := &apiLog{}
 	records := []DNSRecord{
-		{ID: "rec1", Name: "example.com", IP: "fd00::old1", Type: "AAAA"},
-		{ID: "rec2", Name: "example.com", IP: "fd00::old2", Type: "AAAA"},
+		{ID: "rec1", Name: "example.com", IP: "fd00::old1", Type: "AAAA", Comment: record.OwnerMarker},
+		{ID: "rec2", Name: "example.com", IP: "fd00::old2", Type: "AAAA", Comment: record.OwnerMarker},
 	}
 	srv := newMockCloudflare(t, records, calls)
 	defer srv.Close()
 
 	provider := newTestProvider(srv.URL)
-	if err := provider.UpdateIP("example.com", "@", "fd00::current"); err != nil {
+	if err := provider.UpdateIP(context.Background(), "example.com", "@", "fd00::current"); err != nil {
 		t.Fatalf("UpdateIP failed: %v", err)
 	}
 
@@ -155,7 +161,7 @@ func TestCreateWhenMissing(t *testing.T) {
 	defer srv.Close()
 
 	provider := newTestProvider(srv.URL)
-	if err := provider.UpdateIP("example.com", "sub", "fd00::current"); err != nil {
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
 		t.Fatalf("UpdateIP failed: %v", err)
 	}
 
@@ -178,7 +184,7 @@ func TestNoSubstringFalseMatch(t *testing.T) {
 	defer srv.Close()
 
 	provider := newTestProvider(srv.URL)
-	if err := provider.UpdateIP("example.com", "sub", "fd00::current"); err != nil {
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
 		t.Fatalf("UpdateIP failed: %v", err)
 	}
 
@@ -190,3 +196,126 @@ func TestNoSubstringFalseMatch(t *testing.T) {
 		t.Errorf("expected sub.example.com to be created, got %d creations", calls.created)
 	}
 }
+
+func TestGetRecords(t *testing.T) {
+	records := []DNSRecord{
+		{ID: "r1", Name: "sub.example.com", Type: "AAAA", IP: "fd00::1", TTL: 300},
+		{ID: "r2", Name: "example.com", Type: "A", IP: "192.0.2.1", TTL: 1},
+	}
+	calls := &apiLog{}
+	srv := newMockCloudflare(t, records, calls)
+	defer srv.Close()
+
+	provider := newTestProvider(srv.URL)
+	all, err := provider.GetRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "r1" || all[0].Value != "fd00::1" || all[0].TTL != 300 {
+		t.Errorf("unexpected records: %+v", all)
+	}
+
+	rec, err := provider.GetRecord(context.Background(), "example.com", "@", "A")
+	if err != nil || rec == nil || rec.ID != "r2" {
+		t.Errorf("expected the root A record, got %+v (%v)", rec, err)
+	}
+
+	rec, err = provider.GetRecord(context.Background(), "example.com", "missing", "A")
+	if err != nil || rec != nil {
+		t.Errorf("expected no record, got %+v (%v)", rec, err)
+	}
+}
+
+// Records managed by hand are never modified, unless adopted.
+func TestForeignRecords(t *testing.T) {
+	calls := &apiLog{}
+	records := []DNSRecord{
+		{ID: "rec1", Name: "sub.example.com", IP: "fd00::old1", Type: "AAAA", Comment: "set by hand"},
+		{ID: "rec2", Name: "sub.example.com", IP: "fd00::old2", Type: "AAAA", Comment: record.OwnerMarker},
+	}
+	srv := newMockCloudflare(t, records, calls)
+	defer srv.Close()
+
+	// only the owned record is updated, the foreign one is kept
+	provider := newTestProvider(srv.URL)
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
+		t.Fatalf("UpdateIP failed: %v", err)
+	}
+	if len(calls.updated) != 1 || calls.updated[0] != "rec2" || len(calls.deleted) != 0 {
+		t.Errorf("expected only rec2 updated, got updated=%v deleted=%v", calls.updated, calls.deleted)
+	}
+
+	// a hostname holding only foreign records is refused
+	calls = &apiLog{}
+	srv2 := newMockCloudflare(t, records[:1], calls)
+	defer srv2.Close()
+
+	provider = newTestProvider(srv2.URL)
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); !errors.Is(err, utils.ErrNotOwned) {
+		t.Errorf("expected the foreign record to be refused, got %v", err)
+	}
+	if len(calls.updated) != 0 || len(calls.deleted) != 0 || calls.created != 0 {
+		t.Errorf("expected no API mutations, got updated=%v deleted=%v created=%d", calls.updated, calls.deleted, calls.created)
+	}
+
+	// unless it is adopted
+	provider.configuration.Domains[0].Adopt = true
+	if err := provider.UpdateIP(context.Background(), "example.com", "sub", "fd00::current"); err != nil {
+		t.Fatalf("UpdateIP failed: %v", err)
+	}
+	if len(calls.updated) != 1 || calls.updated[0] != "rec1" {
+		t.Errorf("expected rec1 adopted, got %v", calls.updated)
+	}
+}
+
+// Only the records managed by GoDNS are deleted.
+func TestDeleteRecord(t *testing.T) {
+	calls := &apiLog{}
+	records := []DNSRecord{
+		{ID: "rec1", Name: "old.example.com", IP: "fd00::1", Type: "AAAA", Comment: "set by hand"},
+		{ID: "rec2", Name: "old.example.com", IP: "fd00::1", Type: "AAAA", Comment: record.OwnerMarker},
+		{ID: "rec3", Name: "sub.old.example.com", IP: "fd00::1", Type: "AAAA", Comment: record.OwnerMarker},
+	}
+	srv := newMockCloudflare(t, records, calls)
+	defer srv.Close()
+
+	provider := newTestProvider(srv.URL)
+	if err := provider.DeleteRecord(context.Background(), "example.com", "old", "AAAA", "fd00::1"); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	if len(calls.deleted) != 1 || calls.deleted[0] != "rec2" {
+		t.Errorf("expected only rec2 deleted, got %v", calls.deleted)
+	}
+}
+
+// Wildcard and nested subdomains only match their own record, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	content, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response DNSRecordResponse
+	if err := json.Unmarshal(content, &response); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"home":     "372e67954025e0ba6aaa6d586b9e0b59",
+		"*.home":   "a2fd9e0cbb5c0f7f9d3e41c8d1e0b761",
+		"vpn.home": "6c4b1f0b8ad44f7e2b7bd0f1c2e8a9d3",
+	}
+	for subdomain, id := range tests {
+		calls := &apiLog{}
+		srv := newMockCloudflare(t, response.Records, calls)
+
+		provider := newTestProvider(srv.URL)
+		provider.configuration.Domains[0].SubDomains = []string{"home", "*.home", "vpn.home"}
+		if err := provider.UpdateIP(context.Background(), "example.com", subdomain, "192.0.2.10"); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", subdomain, err)
+		}
+		if len(calls.updated) != 1 || calls.updated[0] != id || len(calls.deleted) != 0 || calls.created != 0 {
+			t.Errorf("%s: expected only %s updated, got updated=%v deleted=%v created=%d", subdomain, id, calls.updated, calls.deleted, calls.created)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,88 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains only match their own record, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"@":        "/domains/example.com/records/28448429",
+		"home":     "/domains/example.com/records/28448430",
+		"*.home":   "/domains/example.com/records/28448431",
+		"vpn.home": "/domains/example.com/records/28448432",
+	}
+	for subdomain, path := range tests {
+		var updated []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				_, _ = w.Write(fixture)
+			case http.MethodPut:
+				updated = append(updated, r.URL.Path)
+				_, _ = w.Write([]byte(`{}`))
+			default:
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+		}))
+
+		provider := &DNSProvider{
+			API: srv.URL,
+			configuration: &settings.Settings{
+				LoginToken: "test-token",
+				Domains:    []settings.Domain{{DomainName: "example.com", SubDomains: []string{"@", "home", "*.home", "vpn.home"}}},
+			},
+		}
+		if err := provider.UpdateIP(context.Background(), "example.com", subdomain, "192.0.2.10"); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", subdomain, err)
+		}
+		if !slices.Equal(updated, []string{path}) {
+			t.Errorf("%s: expected only %s updated, got %v", subdomain, path, updated)
+		}
+		srv.Close()
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write(fixture)
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	provider := &DNSProvider{API: srv.URL, configuration: &settings.Settings{LoginToken: "test-token"}}
+	if err := provider.DeleteRecord(context.Background(), "example.com", "*.home", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	if err := provider.DeleteRecord(context.Background(), "example.com", "missing", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord of a missing record failed: %v", err)
+	}
+	if !slices.Equal(deleted, []string{"/domains/example.com/records/28448431"}) {
+		t.Errorf("expected only record 28448431 deleted, got %v", deleted)
+	}
+}
@@ -2,17 +2,31 @@ package digitalocean
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "DigitalOcean"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Create: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL is the endpoint for the DigitalOcean API.
 	URL = "https://api.digitalocean.com/v2"
@@ -48,10 +62,13 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.API = URL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	log.Infof("Checking IP for domain %s", domainName)
 
-	records := provider.getDNSRecords(domainName)
+	records, err := provider.getDNSRecords(ctx, domainName, utils.GetRecordType(ip))
+	if err != nil {
+		return err
+	}
 	matched := false
 
 	// update records
@@ -62,10 +79,16 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 			continue
 		}
 
-		if strings.Contains(rec.Name, subdomainName) || rec.Name == domainName {
+		// the names are relative to the domain, e.g. "@" or "*.home"
+		if rec.Name == subdomainName {
 			if rec.IP != ip {
 				log.Infof("IP mismatch: Current(%+v) vs DigitalOcean(%+v)", ip, rec.IP)
-				provider.updateRecord(domainName, rec, ip)
+				if ttl := provider.configuration.GetRecordOptions(domainName, subdomainName).TTL; ttl > 0 {
+					rec.TTL = int32(ttl)
+				}
+				if err := provider.updateRecord(ctx, domainName, rec, ip); err != nil {
+					return err
+				}
 			} else {
 				log.Infof("Record OK: %+v - %+v", rec.Name, rec.IP)
 			}
@@ -76,7 +99,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 	if !matched {
 		log.Debugf("Record %s not found, will create it.", subdomainName)
-		if err := provider.createRecord(domainName, subdomainName, ip); err != nil {
+		if err := provider.createRecord(ctx, domainName, subdomainName, ip); err != nil {
 			return err
 		}
 		log.Infof("Record [%s] created with IP address: %s", subdomainName, ip)
@@ -85,17 +108,6 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 	return nil
 }
 
-func (provider *DNSProvider) getRecordType() string {
-	var recordType string = utils.IPTypeA
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		recordType = utils.IPTypeA
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		recordType = utils.IPTypeAAAA
-	}
-
-	return recordType
-}
-
 func (provider *DNSProvider) getCurrentDomain(domainName string) *settings.Domain {
 	for _, domain := range provider.configuration.Domains {
 		domain := domain
@@ -119,13 +131,13 @@ func recordTracked(domain *settings.Domain, record *DNSRecord) bool {
 }
 
 // Create a new request with auth in place and optional proxy.
-func (provider *DNSProvider) newRequest(method, url string, body io.Reader) (*http.Request, *http.Client) {
+func (provider *DNSProvider) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, *http.Client) {
 	client := utils.GetHTTPClient(provider.configuration)
 	if client == nil {
 		log.Info("cannot create HTTP client")
 	}
 
-	req, _ := http.NewRequest(method, provider.API+url, body)
+	req, _ := http.NewRequestWithContext(ctx, method, provider.API+url, body)
 	req.Header.Set("Content-Type", "application/json")
 
 	if provider.configuration.Email != "" && provider.configuration.Password != "" {
@@ -139,40 +151,105 @@ func (provider *DNSProvider) newRequest(method, url string, body io.Reader) (*ht
 	return req, client
 }
 
-// Get all DNS A(AAA) records for a zone.
-func (provider *DNSProvider) getDNSRecords(domainName string) []DNSRecord {
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	records, err := provider.getDNSRecords(ctx, domainName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		result = append(result, record.Record{
+			Name:  record.Hostname(domainName, rec.Name),
+			Type:  rec.Type,
+			Value: rec.IP,
+			TTL:   int(rec.TTL),
+			ID:    fmt.Sprint(rec.ID),
+		})
+	}
+	return result, nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	records, err := provider.GetRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+// DeleteRecord deletes the record of the subdomain with the given type.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domainName, subdomainName, recordType, _ string) error {
+	rec, err := provider.GetRecord(ctx, domainName, subdomainName, recordType)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		log.Infof("Record %s (%s) already deleted", record.Hostname(domainName, subdomainName), recordType)
+		return nil
+	}
+
+	req, client := provider.newRequest(ctx, "DELETE", fmt.Sprintf("/domains/%s/records/%s", domainName, rec.ID), nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Error("Request error:", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete record %s: %s", rec.Name, string(body))
+	}
+	log.Infof("Record deleted: %s (ID: %s)", rec.Name, rec.ID)
+	return nil
+}
 
-	var empty []DNSRecord
+// Get all DNS A(AAA) records for a zone, or the records of any type if the type is empty.
+func (provider *DNSProvider) getDNSRecords(ctx context.Context, domainName, recordType string) ([]DNSRecord, error) {
 	var r DomainRecordsResponse
-	recordType := provider.getRecordType()
 
-	log.Infof("Querying records with type: %s", recordType)
-	req, client := provider.newRequest("GET", fmt.Sprintf("/domains/"+domainName+"/records?type=%s&page=1&per_page=200", recordType), nil)
+	url := fmt.Sprintf("/domains/%s/records?page=1&per_page=200", domainName)
+	if recordType != "" {
+		log.Infof("Querying records with type: %s", recordType)
+		url += "&type=" + recordType
+	}
+
+	req, client := provider.newRequest(ctx, "GET", url, nil)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error("Request error:", err)
-		return empty
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return nil, err
 	}
 
 	body, _ := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
-		log.Infof("Decoder error: %+v", err)
 		log.Debugf("Response body: %+v", string(body))
-		return empty
+		return nil, fmt.Errorf("failed to decode the records: %w", err)
 	}
 
-	return r.Records
+	return r.Records, nil
 }
 
-func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
-	recordType := provider.getRecordType()
-
+func (provider *DNSProvider) createRecord(ctx context.Context, domain, subDomain, ip string) error {
 	newRecord := DNSRecord{
-		Type: recordType,
+		Type: utils.GetRecordType(ip),
 		IP:   ip,
 		TTL:  int32(provider.configuration.Interval),
 	}
+	if ttl := provider.configuration.GetRecordOptions(domain, subDomain).TTL; ttl > 0 {
+		newRecord.TTL = int32(ttl)
+	}
 
 	if subDomain == utils.RootDomain {
 		newRecord.Name = utils.RootDomain
@@ -186,7 +263,7 @@ func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
 		return err
 	}
 
-	req, client := provider.newRequest("POST", fmt.Sprintf("/domains/%s/records", domain), bytes.NewBuffer(content))
+	req, client := provider.newRequest(ctx, "POST", fmt.Sprintf("/domains/%s/records", domain), bytes.NewBuffer(content))
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error("Request error:", err)
@@ -194,6 +271,9 @@ func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
 	}
 
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorf("Failed to read request body: %+v", err)
@@ -212,33 +292,33 @@ func (provider *DNSProvider) createRecord(domain, subDomain, ip string) error {
 }
 
 // Update DNS Record with new IP.
-func (provider *DNSProvider) updateRecord(domainName string, record DNSRecord, newIP string) string {
-
+func (provider *DNSProvider) updateRecord(ctx context.Context, domainName string, record DNSRecord, newIP string) error {
 	var r DNSRecord
 	record.SetIP(newIP)
-	var lastIP string
 
 	j, _ := json.Marshal(record)
-	req, client := provider.newRequest("PUT",
+	req, client := provider.newRequest(ctx, "PUT",
 		fmt.Sprintf("/domains/%s/records/%d", domainName, record.ID),
 		bytes.NewBuffer(j),
 	)
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Error("Request error:", err)
-		return ""
+		return err
 	}
 
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 	body, _ := io.ReadAll(resp.Body)
 	err = json.Unmarshal(body, &r)
 	if err != nil {
 		log.Errorf("Decoder error: %+v", err)
 		log.Debugf("Response body: %+v", string(body))
-		return ""
+		return err
 	}
 	log.Infof("Record updated: %+v - %+v", record.Name, record.IP)
-	lastIP = record.IP
 
-	return lastIP
+	return nil
 }
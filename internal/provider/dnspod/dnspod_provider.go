@@ -1,6 +1,7 @@
 package dnspod
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
@@ -17,6 +20,24 @@ import (
 	"github.com/bitly/go-simplejson"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "DNSPod"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+		Validate: func(credentials registry.Credentials) error {
+			if credentials.Password == "" && credentials.LoginToken == "" {
+				return errors.New("password or login token cannot be empty")
+			}
+			return nil
+		},
+	})
+}
+
 const (
 	providerURL = "https://dnsapi.cn"
 )
@@ -24,26 +45,87 @@ const (
 // DNSProvider struct definition.
 type DNSProvider struct {
 	configuration *settings.Settings
+	API           string
 }
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
+	provider.API = providerURL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	domainID := provider.getDomain(domainName)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	domainID := provider.getDomain(ctx, domainName)
 
 	if domainID == -1 {
 		return errors.New("domain ID not found")
 	}
 
-	subdomainID, currentIP := provider.getSubDomain(domainID, subdomainName)
+	subdomainID, currentIP := provider.getSubDomain(ctx, domainID, subdomainName, utils.GetRecordType(ip))
 	if subdomainID == "" || currentIP == "" {
 		return fmt.Errorf("domain or subdomain not configured yet. domain: %s.%s subDomainID: %s ip: %s", subdomainName, domainName, subdomainID, ip)
 	}
 
 	log.Infof("%s.%s Start to update record IP...", subdomainName, domainName)
-	return provider.updateIP(domainID, subdomainID, subdomainName, ip)
+	return provider.updateIP(ctx, domainID, subdomainID, subdomainName, ip)
+}
+
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	domainID := provider.getDomain(ctx, domainName)
+	if domainID <= 0 {
+		return nil, fmt.Errorf("domain ID of %s not found", domainName)
+	}
+
+	return provider.listRecords(ctx, domainName, domainID, url.Values{})
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	domainID := provider.getDomain(ctx, domainName)
+	if domainID <= 0 {
+		return nil, fmt.Errorf("domain ID of %s not found", domainName)
+	}
+
+	filter := url.Values{}
+	filter.Add("sub_domain", subdomainName)
+	filter.Add("record_type", recordType)
+	records, err := provider.listRecords(ctx, domainName, domainID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+// listRecords returns the records of the domain matching the filter.
+func (provider *DNSProvider) listRecords(ctx context.Context, domainName string, domainID int64, filter url.Values) ([]record.Record, error) {
+	filter.Add("domain_id", strconv.FormatInt(domainID, 10))
+	response, err := provider.postData(ctx, "/Record.List", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sjson, err := simplejson.NewJson([]byte(response))
+	if err != nil {
+		return nil, err
+	}
+
+	if sjson.Get("status").Get("code").MustString() != "1" {
+		return nil, fmt.Errorf("failed to list the records: %s", sjson.Get("status").Get("message").MustString())
+	}
+
+	var records []record.Record
+	for i := range sjson.Get("records").MustArray() {
+		rec := sjson.Get("records").GetIndex(i)
+		ttl, _ := strconv.Atoi(rec.Get("ttl").MustString())
+		records = append(records, record.Record{
+			Name:  record.Hostname(domainName, rec.Get("name").MustString()),
+			Type:  rec.Get("type").MustString(),
+			Value: rec.Get("value").MustString(),
+			TTL:   ttl,
+			ID:    rec.Get("id").MustString(),
+		})
+	}
+	return records, nil
 }
 
 // generateHeader generates the request header for DNSPod API.
@@ -65,7 +147,7 @@ func (provider *DNSProvider) generateHeader(content url.Values) url.Values {
 }
 
 // getDomain returns specific domain by name.
-func (provider *DNSProvider) getDomain(name string) int64 {
+func (provider *DNSProvider) getDomain(ctx context.Context, name string) int64 {
 
 	var ret int64
 	values := url.Values{}
@@ -73,7 +155,7 @@ func (provider *DNSProvider) getDomain(name string) int64 {
 	values.Add("offset", "0")
 	values.Add("length", "20")
 
-	response, err := provider.postData("/Domain.List", values)
+	response, err := provider.postData(ctx, "/Domain.List", values)
 
 	if err != nil {
 		log.Error("Failed to get domain list:", err)
@@ -113,25 +195,17 @@ func (provider *DNSProvider) getDomain(name string) int64 {
 	return ret
 }
 
-// getSubDomain returns subdomain by domain id.
-func (provider *DNSProvider) getSubDomain(domainID int64, name string) (string, string) {
+// getSubDomain returns subdomain record of the given type by domain id.
+func (provider *DNSProvider) getSubDomain(ctx context.Context, domainID int64, name, recordType string) (string, string) {
 	var ret, ip string
 	value := url.Values{}
 	value.Add("domain_id", strconv.FormatInt(domainID, 10))
 	value.Add("offset", "0")
 	value.Add("length", "1")
 	value.Add("sub_domain", name)
+	value.Add("record_type", recordType)
 
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		value.Add("record_type", "A")
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		value.Add("record_type", "AAAA")
-	} else {
-		log.Error("Error: must specify \"ip_type\" in config for DNSPod.")
-		return "", ""
-	}
-
-	response, err := provider.postData("/Record.List", value)
+	response, err := provider.postData(ctx, "/Record.List", value)
 
 	if err != nil {
 		log.Error("Failed to get domain list:", err)
@@ -167,25 +241,16 @@ func (provider *DNSProvider) getSubDomain(domainID int64, name string) (string,
 }
 
 // updateIP update subdomain with current IP.
-func (provider *DNSProvider) updateIP(domainID int64, subDomainID string, subDomainName string, ip string) error {
+func (provider *DNSProvider) updateIP(ctx context.Context, domainID int64, subDomainID string, subDomainName string, ip string) error {
 	value := url.Values{}
 	value.Add("domain_id", strconv.FormatInt(domainID, 10))
 	value.Add("record_id", subDomainID)
 	value.Add("sub_domain", subDomainName)
-
-	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		value.Add("record_type", utils.IPTypeA)
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		value.Add("record_type", utils.IPTypeAAAA)
-	} else {
-		log.Error("Must specify ip_type in config for DNSPod.")
-		return errors.New("must specify ip_type in config for DNSPod")
-	}
-
+	value.Add("record_type", utils.GetRecordType(ip))
 	value.Add("record_line", "默认")
 	value.Add("value", ip)
 
-	response, err := provider.postData("/Record.Modify", value)
+	response, err := provider.postData(ctx, "/Record.Modify", value)
 
 	if err != nil {
 		log.Error("Failed to update record to new IP:", err)
@@ -210,7 +275,7 @@ func (provider *DNSProvider) updateIP(domainID int64, subDomainID string, subDom
 }
 
 // postData post data and invoke DNSPod API.
-func (provider *DNSProvider) postData(url string, content url.Values) (string, error) {
+func (provider *DNSProvider) postData(ctx context.Context, url string, content url.Values) (string, error) {
 	client := utils.GetHTTPClient(provider.configuration)
 
 	if client == nil {
@@ -218,7 +283,7 @@ func (provider *DNSProvider) postData(url string, content url.Values) (string, e
 	}
 
 	values := provider.generateHeader(content)
-	req, _ := http.NewRequest("POST", providerURL+url, strings.NewReader(values.Encode()))
+	req, _ := http.NewRequestWithContext(ctx, "POST", provider.API+url, strings.NewReader(values.Encode()))
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", fmt.Sprintf("GoDNS/0.1 (%s)", ""))
@@ -236,6 +301,9 @@ func (provider *DNSProvider) postData(url string, content url.Values) (string, e
 			log.Error("Failed to close body:", err)
 		}
 	}(response.Body)
+	if err := utils.CheckRetryAfter(response); err != nil {
+		return "", err
+	}
 
 	resp, _ := io.ReadAll(response.Body)
 
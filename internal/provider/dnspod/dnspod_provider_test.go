@@ -0,0 +1,73 @@
+package dnspod
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains only match their own record, see
+// testdata/nested_records.json. The records are listed by type only, so that
+// the names are matched by the provider.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Status  json.RawMessage  `json:"status"`
+		Records []map[string]any `json:"records"`
+	}
+	if err := json.Unmarshal(fixture, &list); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdomain string
+		ip        string
+		recordID  string
+	}{
+		{"@", "192.0.2.10", "16894439"},
+		{"home", "192.0.2.10", "16894440"},
+		{"*.home", "192.0.2.10", "16894441"},
+		{"vpn.home", "192.0.2.10", "16894442"},
+		{"vpn.home", "2001:db8::10", "16894443"},
+	}
+	for _, tt := range tests {
+		var modified []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			switch r.URL.Path {
+			case "/Domain.List":
+				_, _ = w.Write([]byte(`{"status":{"code":"1"},"domains":[{"id":9300000,"name":"example.com"}]}`))
+			case "/Record.List":
+				records := []map[string]any{}
+				for _, rec := range list.Records {
+					if rec["type"] == r.Form.Get("record_type") {
+						records = append(records, rec)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"status": list.Status, "records": records})
+			case "/Record.Modify":
+				modified = append(modified, r.Form.Get("record_id")+"="+r.Form.Get("sub_domain"))
+				_, _ = w.Write([]byte(`{"status":{"code":"1"}}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+			}
+		}))
+
+		provider := &DNSProvider{API: srv.URL, configuration: &settings.Settings{LoginToken: "id,token"}}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if want := tt.recordID + "=" + tt.subdomain; len(modified) != 1 || modified[0] != want {
+			t.Errorf("%s (%s): expected only %s modified, got %v", tt.subdomain, tt.ip, want, modified)
+		}
+		srv.Close()
+	}
+}
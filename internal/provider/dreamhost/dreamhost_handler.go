@@ -1,12 +1,15 @@
 package dreamhost
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
@@ -14,6 +17,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Dreamhost"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, Create: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for dreamhost.com.
 	URL = "https://api.dreamhost.com"
@@ -29,35 +44,35 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	hostname := subdomainName + "." + domainName
-	lastIP, err := utils.ResolveDNS(hostname, provider.configuration.Resolver, provider.configuration.IPType)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	hostname := record.Hostname(domainName, subdomainName)
+	lastIP, err := utils.ResolveDNS(hostname, provider.configuration.Resolver, utils.GetIPType(ip))
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 
-	return provider.updateIP(hostname, ip, lastIP)
+	return provider.updateIP(ctx, hostname, ip, lastIP)
+}
+
+// DeleteRecord removes the record of the subdomain holding the given value.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domainName, subdomainName, _, value string) error {
+	return provider.updateDNS(ctx, value, value, record.Hostname(domainName, subdomainName), "remove")
 }
 
 // updateIP update subdomain with current IP.
-func (provider *DNSProvider) updateIP(hostname, currentIP, lastIP string) error {
+func (provider *DNSProvider) updateIP(ctx context.Context, hostname, currentIP, lastIP string) error {
 
-	if err := provider.updateDNS(lastIP, currentIP, hostname, "remove"); err != nil {
+	if err := provider.updateDNS(ctx, lastIP, currentIP, hostname, "remove"); err != nil {
 		return err
 	}
 
-	return provider.updateDNS(lastIP, currentIP, hostname, "add")
+	return provider.updateDNS(ctx, lastIP, currentIP, hostname, "add")
 }
 
 // updateDNS can add or remove DNS records.
-func (provider *DNSProvider) updateDNS(dns, ip, hostname, action string) error {
-	var ipType string
-	if provider.configuration.IPType == "" || strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		ipType = utils.IPTypeA
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		ipType = utils.IPTypeAAAA
-	}
+func (provider *DNSProvider) updateDNS(ctx context.Context, dns, ip, hostname, action string) error {
+	ipType := utils.GetRecordType(ip)
 
 	// Generates UUID
 	uid, _ := uuid.NewRandom()
@@ -81,7 +96,7 @@ func (provider *DNSProvider) updateDNS(dns, ip, hostname, action string) error {
 	}
 
 	client := utils.GetHTTPClient(provider.configuration)
-	req, _ := http.NewRequest("POST", URL, strings.NewReader(values.Encode()))
+	req, _ := http.NewRequestWithContext(ctx, "POST", URL, strings.NewReader(values.Encode()))
 	req.SetBasicAuth(provider.configuration.Email, provider.configuration.Password)
 
 	if provider.configuration.UserAgent != "" {
@@ -100,6 +115,9 @@ func (provider *DNSProvider) updateDNS(dns, ip, hostname, action string) error {
 			log.Error("Failed to close the request body:", err)
 		}
 	}(resp.Body)
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
@@ -1,16 +1,29 @@
 package duck
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "DuckDNS"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for Duck DNS.
 	URL = "https://www.duckdns.org/update?domains=%s&token=%s&%s"
@@ -28,21 +41,26 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.client = utils.GetHTTPClient(provider.configuration)
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	return provider.updateIP(domainName, subdomainName, ip)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	return provider.updateIP(ctx, domainName, subdomainName, ip)
 }
 
-func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP string) error {
+func (provider *DNSProvider) updateIP(ctx context.Context, domainName, subdomainName, currentIP string) error {
 	var ip string
 
-	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
+	if utils.GetIPType(currentIP) == utils.IPV4 {
 		ip = fmt.Sprintf("ip=%s", currentIP)
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
+	} else {
 		ip = fmt.Sprintf("ipv6=%s", currentIP)
 	}
 
 	// update IP with HTTP GET request
-	resp, err := provider.client.Get(fmt.Sprintf(URL, subdomainName, provider.configuration.LoginToken, ip))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(URL, subdomainName, provider.configuration.LoginToken, ip), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := provider.client.Do(req)
 	if err != nil {
 		// handle error
 		log.Errorf("Failed to update sub domain: %s.%s, error: %s", domainName, subdomainName, err)
@@ -55,6 +73,9 @@ func (provider *DNSProvider) updateIP(domainName, subdomainName, currentIP strin
 			log.Error(err)
 		}
 	}(resp.Body)
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil || string(body) != "OK" {
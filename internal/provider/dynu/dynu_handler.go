@@ -1,16 +1,30 @@
 package dynu
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Dynu"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		Password:     true,
+		Capabilities: registry.Capabilities{IPv6: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for dynu.
 	URL = "https://api.dynu.com/nic/update?hostname=%s&password=%s&%s"
@@ -26,21 +40,21 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	hostname := subdomainName + "." + domainName
 	client := utils.GetHTTPClient(provider.configuration)
-	return provider.update(client, hostname, subdomainName, ip)
+	return provider.update(ctx, client, hostname, subdomainName, ip)
 }
 
-func (provider *DNSProvider) update(client *http.Client, hostname, subdomain string, currentIP string) error {
+func (provider *DNSProvider) update(ctx context.Context, client *http.Client, hostname, subdomain string, currentIP string) error {
 	var ip string
-	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
+	if utils.GetIPType(currentIP) == utils.IPV4 {
 		ip = fmt.Sprintf("myip=%s", currentIP)
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
+	} else {
 		ip = fmt.Sprintf("myipv6=%s", currentIP)
 	}
 
-	req, _ := http.NewRequest("GET", fmt.Sprintf(
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(
 		URL,
 		hostname,
 		utils.GetMD5Hash(provider.configuration.Password),
@@ -64,6 +78,9 @@ func (provider *DNSProvider) update(client *http.Client, hostname, subdomain str
 			log.Error(err)
 		}
 	}(resp.Body)
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil || !strings.Contains(string(body), "good") {
@@ -1,16 +1,30 @@
 package dynv6
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Dynv6"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for Duck DNS.
 	URL = "https://dynv6.com/api/update?hostname=%s&token=%s&%s"
@@ -26,24 +40,29 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	hostname := subdomainName + "." + domainName
 	client := utils.GetHTTPClient(provider.configuration)
-	return provider.update(client, hostname, ip)
+	return provider.update(ctx, client, hostname, ip)
 }
 
-func (provider *DNSProvider) update(client *http.Client, hostname string, currentIP string) error {
+func (provider *DNSProvider) update(ctx context.Context, client *http.Client, hostname string, currentIP string) error {
 	var ip string
-	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
+	if utils.GetIPType(currentIP) == utils.IPV4 {
 		ip = fmt.Sprintf("ipv4=%s", currentIP)
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
+	} else {
 		ip = fmt.Sprintf("ipv6=%s", currentIP)
 	}
 
 	// update IP with HTTP GET request
 	url := fmt.Sprintf(URL, hostname, provider.configuration.LoginToken, ip)
 	log.Debug("Update url: ", url)
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		// handle error
 		log.Errorf("Cannot send request: %s", err.Error())
@@ -56,6 +75,9 @@ func (provider *DNSProvider) update(client *http.Client, hostname string, curren
 			log.Error(err)
 		}
 	}(resp.Body)
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -3,38 +3,40 @@ package provider
 import (
 	"fmt"
 
-	"github.com/TimothyYe/godns/internal/provider/alidns"
-	"github.com/TimothyYe/godns/internal/provider/cloudflare"
-	"github.com/TimothyYe/godns/internal/provider/digitalocean"
-	"github.com/TimothyYe/godns/internal/provider/dnspod"
-	"github.com/TimothyYe/godns/internal/provider/dreamhost"
-	"github.com/TimothyYe/godns/internal/provider/duck"
-	"github.com/TimothyYe/godns/internal/provider/dynu"
-	"github.com/TimothyYe/godns/internal/provider/dynv6"
-	"github.com/TimothyYe/godns/internal/provider/google"
-	"github.com/TimothyYe/godns/internal/provider/he"
-	"github.com/TimothyYe/godns/internal/provider/hetzner"
-	"github.com/TimothyYe/godns/internal/provider/infomaniak"
-	"github.com/TimothyYe/godns/internal/provider/ionos"
-	"github.com/TimothyYe/godns/internal/provider/linode"
-	"github.com/TimothyYe/godns/internal/provider/loopiase"
-	"github.com/TimothyYe/godns/internal/provider/noip"
-	"github.com/TimothyYe/godns/internal/provider/ovh"
-	"github.com/TimothyYe/godns/internal/provider/porkbun"
-	"github.com/TimothyYe/godns/internal/provider/scaleway"
-	"github.com/TimothyYe/godns/internal/provider/strato"
-	"github.com/TimothyYe/godns/internal/provider/transip"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
-	"github.com/TimothyYe/godns/internal/utils"
+
+	// the provider packages register themselves in the registry
+	_ "github.com/TimothyYe/godns/internal/provider/alidns"
+	_ "github.com/TimothyYe/godns/internal/provider/cloudflare"
+	_ "github.com/TimothyYe/godns/internal/provider/digitalocean"
+	_ "github.com/TimothyYe/godns/internal/provider/dnspod"
+	_ "github.com/TimothyYe/godns/internal/provider/dreamhost"
+	_ "github.com/TimothyYe/godns/internal/provider/duck"
+	_ "github.com/TimothyYe/godns/internal/provider/dynu"
+	_ "github.com/TimothyYe/godns/internal/provider/dynv6"
+	_ "github.com/TimothyYe/godns/internal/provider/google"
+	_ "github.com/TimothyYe/godns/internal/provider/he"
+	_ "github.com/TimothyYe/godns/internal/provider/hetzner"
+	_ "github.com/TimothyYe/godns/internal/provider/infomaniak"
+	_ "github.com/TimothyYe/godns/internal/provider/ionos"
+	_ "github.com/TimothyYe/godns/internal/provider/linode"
+	_ "github.com/TimothyYe/godns/internal/provider/loopiase"
+	_ "github.com/TimothyYe/godns/internal/provider/noip"
+	_ "github.com/TimothyYe/godns/internal/provider/ovh"
+	_ "github.com/TimothyYe/godns/internal/provider/porkbun"
+	_ "github.com/TimothyYe/godns/internal/provider/scaleway"
+	_ "github.com/TimothyYe/godns/internal/provider/strato"
+	_ "github.com/TimothyYe/godns/internal/provider/transip"
 )
 
-func GetProvider(conf *settings.Settings) (IDNSProvider, error) {
+func GetProvider(conf *settings.Settings) (IDNSProviderV2, error) {
 	return createProvider(conf.Provider, conf)
 }
 
 // GetProviders returns a map of all configured providers for multi-provider support.
-func GetProviders(conf *settings.Settings) (map[string]IDNSProvider, error) {
-	providers := make(map[string]IDNSProvider)
+func GetProviders(conf *settings.Settings) (map[string]IDNSProviderV2, error) {
+	providers := make(map[string]IDNSProviderV2)
 
 	// Handle legacy single provider mode
 	if !conf.IsMultiProvider() {
@@ -60,27 +62,15 @@ func GetProviders(conf *settings.Settings) (map[string]IDNSProvider, error) {
 	}
 
 	// Then add providers from the providers section
-	for providerName, providerConfig := range conf.Providers {
+	for providerName := range conf.Providers {
 		// Skip if this provider already exists (global provider takes precedence)
 		if _, exists := providers[providerName]; exists {
 			continue
 		}
 
-		// Create a temporary settings object with provider-specific config
-		tempSettings := *conf
-		tempSettings.Provider = providerName
-		tempSettings.Email = providerConfig.Email
-		tempSettings.Password = providerConfig.Password
-		tempSettings.PasswordFile = providerConfig.PasswordFile
-		tempSettings.LoginToken = providerConfig.LoginToken
-		tempSettings.LoginTokenFile = providerConfig.LoginTokenFile
-		tempSettings.AppKey = providerConfig.AppKey
-		tempSettings.AppSecret = providerConfig.AppSecret
-		tempSettings.ConsumerKey = providerConfig.ConsumerKey
-
-		provider, err := createProvider(providerName, &tempSettings)
+		provider, err := GetProviderByName(conf, providerName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create provider %s: %w", providerName, err)
+			return nil, err
 		}
 
 		providers[providerName] = provider
@@ -89,8 +79,45 @@ func GetProviders(conf *settings.Settings) (map[string]IDNSProvider, error) {
 	return providers, nil
 }
 
+// GetProviderByName creates a single provider of a multi-provider configuration,
+// either the global provider or one of the providers section.
+func GetProviderByName(conf *settings.Settings, providerName string) (IDNSProviderV2, error) {
+	if providerName == conf.Provider {
+		provider, err := createProvider(conf.Provider, conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create global provider %s: %w", conf.Provider, err)
+		}
+		return provider, nil
+	}
+
+	providerConfig, exists := conf.Providers[providerName]
+	if !exists {
+		return nil, fmt.Errorf("provider '%s' is not configured", providerName)
+	}
+
+	// Create a temporary settings object with provider-specific config
+	providerType := conf.GetProviderType(providerName)
+	tempSettings := *conf
+	tempSettings.Provider = providerType
+	tempSettings.Email = providerConfig.Email
+	tempSettings.Password = providerConfig.Password
+	tempSettings.PasswordFile = providerConfig.PasswordFile
+	tempSettings.LoginToken = providerConfig.LoginToken
+	tempSettings.LoginTokenFile = providerConfig.LoginTokenFile
+	tempSettings.AppKey = providerConfig.AppKey
+	tempSettings.AppSecret = providerConfig.AppSecret
+	tempSettings.ConsumerKey = providerConfig.ConsumerKey
+
+	provider, err := createProvider(providerType, &tempSettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider %s: %w", providerName, err)
+	}
+
+	return provider, nil
+}
+
 // GetProviderForDomain returns the appropriate provider for a given domain.
-func GetProviderForDomain(domain *settings.Domain, providers map[string]IDNSProvider, conf *settings.Settings) (IDNSProvider, error) {
+func GetProviderForDomain(domain *settings.Domain, providers map[string]IDNSProviderV2, conf *settings.Settings) (IDNSProviderV2, error) {
 	providerName := conf.GetDomainProvider(domain)
 
 	provider, exists := providers[providerName]
@@ -101,56 +128,23 @@ func GetProviderForDomain(domain *settings.Domain, providers map[string]IDNSProv
 	return provider, nil
 }
 
-func createProvider(providerName string, conf *settings.Settings) (IDNSProvider, error) {
-	var provider IDNSProvider
-
-	switch providerName {
-	case utils.CLOUDFLARE:
-		provider = &cloudflare.DNSProvider{}
-	case utils.DIGITALOCEAN:
-		provider = &digitalocean.DNSProvider{}
-	case utils.DNSPOD:
-		provider = &dnspod.DNSProvider{}
-	case utils.DREAMHOST:
-		provider = &dreamhost.DNSProvider{}
-	case utils.HE:
-		provider = &he.DNSProvider{}
-	case utils.ALIDNS:
-		provider = &alidns.DNSProvider{}
-	case utils.GOOGLE:
-		provider = &google.DNSProvider{}
-	case utils.DUCK:
-		provider = &duck.DNSProvider{}
-	case utils.NOIP:
-		provider = &noip.DNSProvider{}
-	case utils.SCALEWAY:
-		provider = &scaleway.DNSProvider{}
-	case utils.DYNV6:
-		provider = &dynv6.DNSProvider{}
-	case utils.LINODE:
-		provider = &linode.DNSProvider{}
-	case utils.STRATO:
-		provider = &strato.DNSProvider{}
-	case utils.LOOPIASE:
-		provider = &loopiase.DNSProvider{}
-	case utils.INFOMANIAK:
-		provider = &infomaniak.DNSProvider{}
-	case utils.HETZNER:
-		provider = &hetzner.DNSProvider{}
-	case utils.OVH:
-		provider = &ovh.DNSProvider{}
-	case utils.DYNU:
-		provider = &dynu.DNSProvider{}
-	case utils.IONOS:
-		provider = &ionos.DNSProvider{}
-	case utils.TRANSIP:
-		provider = &transip.DNSProvider{}
-	case utils.PORKBUN:
-		provider = &porkbun.DNSProvider{}
-	default:
+// createProvider creates and initializes a provider registered under the given name.
+func createProvider(providerName string, conf *settings.Settings) (IDNSProviderV2, error) {
+	entry, exists := registry.Get(providerName)
+	if !exists {
 		return nil, fmt.Errorf("unknown provider '%s'", providerName)
 	}
 
+	var provider IDNSProviderV2
+	switch instance := entry.New().(type) {
+	case IDNSProviderV2:
+		provider = instance
+	case IDNSProvider:
+		provider = AdaptLegacy(instance)
+	default:
+		return nil, fmt.Errorf("provider '%s' implements no provider interface", providerName)
+	}
+
 	provider.Init(conf)
 	return provider, nil
 }
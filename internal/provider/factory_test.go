@@ -3,6 +3,8 @@ package provider
 import (
 	"testing"
 
+	"github.com/TimothyYe/godns/internal/provider/cloudflare"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 )
 
@@ -143,6 +145,39 @@ func TestGetProviders(t *testing.T) {
 			}
 		})
 
+		// Test several accounts of the same provider type
+		t.Run("NamedProviders", func(t *testing.T) {
+			config := &settings.Settings{
+				Providers: map[string]*settings.ProviderConfig{
+					"cf-home": {Type: "Cloudflare", LoginToken: "home-token"},
+					"cf-work": {Type: "Cloudflare", LoginToken: "work-token"},
+				},
+				Domains: []settings.Domain{
+					{DomainName: "example.com", SubDomains: []string{"www"}, Provider: "cf-home"},
+					{DomainName: "example.org", SubDomains: []string{"www"}, Provider: "cf-work"},
+				},
+			}
+
+			providers, err := GetProviders(config)
+			if err != nil {
+				t.Fatalf("Failed to get providers: %v", err)
+			}
+
+			for _, providerName := range []string{"cf-home", "cf-work"} {
+				if _, ok := providers[providerName].(*cloudflare.DNSProvider); !ok {
+					t.Errorf("expected %s to be a Cloudflare provider, got %T", providerName, providers[providerName])
+				}
+			}
+			if providers["cf-home"] == providers["cf-work"] {
+				t.Error("expected one provider per account")
+			}
+
+			config.Providers["cf-work"].Type = "Unknown"
+			if _, err := GetProviders(config); err == nil {
+				t.Error("expected an unknown provider type to fail")
+			}
+		})
+
 		// Test empty providers map with no global provider - should fail
 		t.Run("EmptyProviders", func(t *testing.T) {
 			config := &settings.Settings{
@@ -538,3 +573,21 @@ func TestCreateProvider(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisteredProviders(t *testing.T) {
+	providers := registry.Providers()
+	if len(providers) == 0 {
+		t.Fatal("expected the providers to be registered")
+	}
+
+	conf := &settings.Settings{Email: "test@example.com", Password: "test-password", LoginToken: "test-token"}
+	for _, entry := range providers {
+		if _, err := createProvider(entry.Name, conf); err != nil {
+			t.Errorf("failed to create %s: %s", entry.Name, err)
+		}
+	}
+
+	if _, err := createProvider("Unknown", conf); err == nil {
+		t.Error("expected an unknown provider to fail")
+	}
+}
@@ -1,17 +1,31 @@
 package he
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "HE"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		Password:     true,
+		Capabilities: registry.Capabilities{IPv6: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for he.net.
 	URL = "https://dyn.dns.he.net/nic/update"
@@ -27,12 +41,12 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	return provider.updateIP(domainName, subdomainName, ip)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	return provider.updateIP(ctx, domainName, subdomainName, ip)
 }
 
 // updateIP update subdomain with current IP.
-func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
+func (provider *DNSProvider) updateIP(ctx context.Context, domain, subDomain, currentIP string) error {
 	values := url.Values{}
 
 	if subDomain != utils.RootDomain {
@@ -45,7 +59,7 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 
 	client := utils.GetHTTPClient(provider.configuration)
 
-	req, err := http.NewRequest("POST", URL, strings.NewReader(values.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", URL, strings.NewReader(values.Encode()))
 	if err != nil {
 		log.Error("Failed to build request:", err)
 		return err
@@ -57,6 +71,9 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 		return err
 	}
 	defer resp.Body.Close()
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode == http.StatusOK {
@@ -2,17 +2,32 @@ package hetzner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 
 	"net/http"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Hetzner"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	// URL the API address for Hetzner.
 	BaseURL = "https://dns.hetzner.com/api/v1/"
@@ -31,39 +46,100 @@ type Record struct {
 type DNSProvider struct {
 	configuration *settings.Settings
 	client        *http.Client
+	API           string
 }
 
 // Init passes DNS settings and store it to the provider instance.
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 	provider.client = utils.GetHTTPClient(provider.configuration)
+	provider.API = BaseURL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 
-	zoneID, err := provider.getZoneID(domainName)
+	zoneID, err := provider.getZoneID(ctx, domainName)
 	if err != nil {
 		log.Error("Failed to get ZoneID")
 		return err
 	}
 
-	record, err := provider.getRecord(subdomainName, zoneID, provider.configuration.IPType)
+	record, err := provider.getRecord(ctx, subdomainName, zoneID, utils.GetRecordType(ip))
 
 	if err != nil {
 		log.Error("Failed to get Record")
 		return err
 	}
 	record.Value = ip
+	if ttl := provider.configuration.GetRecordOptions(domainName, subdomainName).TTL; ttl > 0 {
+		record.TTL = int64(ttl)
+	}
 
-	err = provider.updateRecord(record)
+	err = provider.updateRecord(ctx, record)
 	if err != nil {
 		log.Error("Update of Record failed")
 	}
 	return err
 }
-func (provider *DNSProvider) getData(endpoint string, param string, value string) ([]byte, error) {
 
-	req, err := http.NewRequest("GET", BaseURL+endpoint, nil)
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	zoneID, err := provider.getZoneID(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	if zoneID == "" {
+		return nil, fmt.Errorf("failed to find zone for domain: %s", domainName)
+	}
+
+	records, err := provider.listRecords(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		result = append(result, record.Record{
+			Name:  record.Hostname(domainName, rec.Name),
+			Type:  rec.Type,
+			Value: rec.Value,
+			TTL:   int(rec.TTL),
+			ID:    rec.ID,
+		})
+	}
+	return result, nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	records, err := provider.GetRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+// DeleteRecord deletes the record of the subdomain with the given type.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domainName, subdomainName, recordType, _ string) error {
+	rec, err := provider.GetRecord(ctx, domainName, subdomainName, recordType)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		log.Infof("Record %s (%s) already deleted", record.Hostname(domainName, subdomainName), recordType)
+		return nil
+	}
+
+	if err := provider.deleteData(ctx, "records", rec.ID); err != nil {
+		return err
+	}
+	log.Infof("Record deleted: %s (ID: %s)", rec.Name, rec.ID)
+	return nil
+}
+
+func (provider *DNSProvider) getData(ctx context.Context, endpoint string, param string, value string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, "GET", provider.API+endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -82,12 +158,16 @@ func (provider *DNSProvider) getData(endpoint string, param string, value string
 	}
 	defer resp.Body.Close()
 
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return nil, err
+	}
+
 	respBody, _ := io.ReadAll(resp.Body)
 	return respBody, nil
 }
-func (provider *DNSProvider) putData(endpoint string, location string, body []byte) error {
+func (provider *DNSProvider) putData(ctx context.Context, endpoint string, location string, body []byte) error {
 
-	req, err := http.NewRequest("PUT", BaseURL+endpoint+"/"+location, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", provider.API+endpoint+"/"+location, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -103,13 +183,45 @@ func (provider *DNSProvider) putData(endpoint string, location string, body []by
 	}
 	defer resp.Body.Close()
 
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		log.Error("Got non 200 status code: ", resp.Status)
 		return fmt.Errorf("got non 200 status code %s", resp.Status)
 	}
 	return nil
 }
-func (provider *DNSProvider) getZoneID(zoneName string) (string, error) {
+
+// deleteData deletes the object of the endpoint at the given location.
+func (provider *DNSProvider) deleteData(ctx context.Context, endpoint string, location string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", provider.API+endpoint+"/"+location, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Auth-API-Token", provider.configuration.LoginToken)
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		log.Error("Delete failed")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Error("Got non 200 status code: ", resp.Status)
+		return fmt.Errorf("got non 200 status code %s", resp.Status)
+	}
+	return nil
+}
+
+func (provider *DNSProvider) getZoneID(ctx context.Context, zoneName string) (string, error) {
 
 	type Zone struct {
 		ID string `json:"id"`
@@ -119,7 +231,7 @@ func (provider *DNSProvider) getZoneID(zoneName string) (string, error) {
 		Zones []Zone `json:"zones"`
 	}
 
-	respBody, err := provider.getData("zones", "name", zoneName)
+	respBody, err := provider.getData(ctx, "zones", "name", zoneName)
 	if err != nil {
 		return "", err
 	}
@@ -137,37 +249,39 @@ func (provider *DNSProvider) getZoneID(zoneName string) (string, error) {
 	return response.Zones[0].ID, nil
 }
 
-func (provider *DNSProvider) getRecord(recordName string, zoneID string, Type string) (Record, error) {
-
+// listRecords returns all the records of the zone.
+func (provider *DNSProvider) listRecords(ctx context.Context, zoneID string) ([]Record, error) {
 	type GetRecordsResult struct {
 		Records []Record `json:"records"`
 	}
 	response := GetRecordsResult{}
-	respBody, err := provider.getData("records", "zone_id", zoneID)
+	respBody, err := provider.getData(ctx, "records", "zone_id", zoneID)
 	if err != nil {
-		return Record{}, err
+		return nil, err
 	}
-	err = json.Unmarshal(respBody, &response)
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+	return response.Records, nil
+}
+
+func (provider *DNSProvider) getRecord(ctx context.Context, recordName string, zoneID string, Type string) (Record, error) {
+	records, err := provider.listRecords(ctx, zoneID)
 	if err != nil {
 		return Record{}, err
 	}
-	if len(response.Records) == 0 {
+	if len(records) == 0 {
 		log.Error("Zone doesn't have any records")
 		return Record{}, fmt.Errorf("zone doesn't have an records")
 	}
 	outRecord := Record{}
-	if Type == "IPv6" {
-		Type = utils.IPTypeAAAA
-	} else {
-		Type = utils.IPTypeA
-	}
 	found := false
 
-	for _, record := range response.Records {
+	for _, rec := range records {
 
-		if record.Name == recordName && record.Type == Type {
+		if rec.Name == recordName && rec.Type == Type {
 			found = true
-			outRecord = record
+			outRecord = rec
 			break
 		}
 	}
@@ -177,9 +291,9 @@ func (provider *DNSProvider) getRecord(recordName string, zoneID string, Type st
 	return outRecord, fmt.Errorf("no record matching value and type found")
 
 }
-func (provider *DNSProvider) updateRecord(record Record) error {
+func (provider *DNSProvider) updateRecord(ctx context.Context, record Record) error {
 	recordJSON, _ := json.Marshal(record)
-	err := provider.putData("records", record.ID, recordJSON)
+	err := provider.putData(ctx, "records", record.ID, recordJSON)
 	return err
 
 }
@@ -0,0 +1,95 @@
+package hetzner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// newMockHetzner serves the zone of example.com with the records of testdata/nested_records.json.
+func newMockHetzner(t *testing.T, handle func(w http.ResponseWriter, r *http.Request)) *DNSProvider {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			_, _ = w.Write([]byte(`{"zones":[{"id":"HBDpfRTj7YHzXzW5cVuiHA"}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/records":
+			_, _ = w.Write(fixture)
+		default:
+			handle(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return &DNSProvider{
+		API:           srv.URL + "/",
+		client:        srv.Client(),
+		configuration: &settings.Settings{LoginToken: "test-token"},
+	}
+}
+
+// Wildcard and nested subdomains only match their own record, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	tests := []struct {
+		subdomain string
+		ip        string
+		path      string
+	}{
+		{"@", "192.0.2.10", "/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f80"},
+		{"home", "192.0.2.10", "/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f81"},
+		{"*.home", "192.0.2.10", "/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f82"},
+		{"vpn.home", "192.0.2.10", "/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f83"},
+		{"vpn.home", "2001:db8::10", "/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f84"},
+	}
+	for _, tt := range tests {
+		var updated []string
+		var body Record
+		provider := newMockHetzner(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+			updated = append(updated, r.URL.Path)
+			_ = json.NewDecoder(r.Body).Decode(&body)
+		})
+
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if !slices.Equal(updated, []string{tt.path}) {
+			t.Errorf("%s (%s): expected only %s updated, got %v", tt.subdomain, tt.ip, tt.path, updated)
+		}
+		if body.Name != tt.subdomain || body.Value != tt.ip {
+			t.Errorf("%s (%s): unexpected record sent: %+v", tt.subdomain, tt.ip, body)
+		}
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	var deleted []string
+	provider := newMockHetzner(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		deleted = append(deleted, r.URL.Path)
+	})
+
+	if err := provider.DeleteRecord(context.Background(), "example.com", "*.home", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	if err := provider.DeleteRecord(context.Background(), "example.com", "missing", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord of a missing record failed: %v", err)
+	}
+	if !slices.Equal(deleted, []string{"/records/7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f82"}) {
+		t.Errorf("expected only record 7f1f8c4e6a0b4c1e9d2a3b4c5d6e7f82 deleted, got %v", deleted)
+	}
+}
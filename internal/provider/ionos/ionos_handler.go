@@ -4,16 +4,31 @@ package ionos
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "IONOS"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	BaseURL = "https://api.hosting.ionos.com/dns/v1/"
 )
@@ -22,21 +37,24 @@ const (
 type DNSProvider struct {
 	configuration *settings.Settings
 	client        *http.Client
+	API           string
 }
 
 // Init passes DNS settings and store it to the provider instance.
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 	provider.client = utils.GetHTTPClient(provider.configuration)
+	provider.API = BaseURL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	zoneID, err := provider.getZoneID(domainName)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	zoneID, err := provider.getZoneID(ctx, domainName)
 	if err != nil {
 		return err
 	}
 
-	recordID, currIP, err := provider.getRecord(zoneID, subdomainName+"."+domainName)
+	hostname := record.Hostname(domainName, subdomainName)
+	recordID, currIP, err := provider.getRecord(ctx, zoneID, hostname, utils.GetRecordType(ip))
 	if err != nil {
 		return err
 	}
@@ -45,11 +63,11 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 		return nil
 	}
 
-	return provider.updateRecord(zoneID, recordID, subdomainName+"."+domainName, ip)
+	return provider.updateRecord(ctx, zoneID, recordID, hostname, ip)
 }
 
-func (provider *DNSProvider) getData(endpoint string, params map[string]string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, BaseURL+endpoint, nil)
+func (provider *DNSProvider) getData(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.API+endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -68,16 +86,20 @@ func (provider *DNSProvider) getData(endpoint string, params map[string]string)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get data from %s, status code: %s", BaseURL+endpoint, resp.Status)
+		return nil, fmt.Errorf("failed to get data from %s, status code: %s", provider.API+endpoint, resp.Status)
 	}
-	defer resp.Body.Close()
 
 	return io.ReadAll(resp.Body)
 
 }
 
-func (provider *DNSProvider) putData(endpoint string, params map[string]any) error {
+func (provider *DNSProvider) putData(ctx context.Context, endpoint string, params map[string]any) error {
 
 	var body []byte
 	var err error
@@ -88,7 +110,7 @@ func (provider *DNSProvider) putData(endpoint string, params map[string]any) err
 		}
 	}
 
-	req, err := http.NewRequest(http.MethodPut, BaseURL+endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, provider.API+endpoint, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -100,10 +122,14 @@ func (provider *DNSProvider) putData(endpoint string, params map[string]any) err
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed to PUT %s, status: %s", endpoint, resp.Status)
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
@@ -114,9 +140,9 @@ type zoneResponse struct {
 	Type string `json:"type"`
 }
 
-func (provider *DNSProvider) getZoneID(domainName string) (string, error) {
+func (provider *DNSProvider) getZoneID(ctx context.Context, domainName string) (string, error) {
 
-	body, err := provider.getData("zones", nil)
+	body, err := provider.getData(ctx, "zones", nil)
 	if err != nil {
 		return "", err
 	}
@@ -152,14 +178,9 @@ type recordListResponse struct {
 	Records []recordResponse `json:"records"`
 }
 
-func (provider *DNSProvider) getRecord(zoneID, recordName string) (id string, ip string, err error) {
-
-	ipType := utils.IPTypeA
-	if provider.configuration.IPType == utils.IPV6 || provider.configuration.IPType == utils.IPTypeAAAA {
-		ipType = utils.IPTypeAAAA
-	}
+func (provider *DNSProvider) getRecord(ctx context.Context, zoneID, recordName, ipType string) (id string, ip string, err error) {
 
-	body, err := provider.getData(fmt.Sprintf("zones/%s", zoneID),
+	body, err := provider.getData(ctx, fmt.Sprintf("zones/%s", zoneID),
 		map[string]string{
 			"recordName": recordName,
 			"recordType": ipType,
@@ -181,9 +202,9 @@ func (provider *DNSProvider) getRecord(zoneID, recordName string) (id string, ip
 	return "", "", fmt.Errorf("record %s not found", recordName)
 }
 
-func (provider *DNSProvider) updateRecord(zoneID, recordID, recordName, ip string) error {
+func (provider *DNSProvider) updateRecord(ctx context.Context, zoneID, recordID, recordName, ip string) error {
 
-	err := provider.putData(fmt.Sprintf("zones/%s/records/%s", zoneID, recordID), map[string]any{"content": ip})
+	err := provider.putData(ctx, fmt.Sprintf("zones/%s/records/%s", zoneID, recordID), map[string]any{"content": ip})
 	if err != nil {
 		return fmt.Errorf("failed to update record %s: %w", recordName, err)
 	}
@@ -0,0 +1,68 @@
+package ionos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains are looked up by their full name, see
+// testdata/nested_records.json. The zone is filtered like the API does.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zone recordListResponse
+	if err := json.Unmarshal(fixture, &zone); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdomain string
+		ip        string
+		path      string
+	}{
+		{"@", "192.0.2.10", "/zones/11af3414-ebba-11e9-8df5-66fbe8a334b4/records/22af3414-abbe-9e11-5df5-66fbe8e334b4"},
+		{"home", "192.0.2.10", "/zones/11af3414-ebba-11e9-8df5-66fbe8a334b4/records/22af3414-abbe-9e11-5df5-66fbe8e334b5"},
+		{"*.home", "192.0.2.10", "/zones/11af3414-ebba-11e9-8df5-66fbe8a334b4/records/22af3414-abbe-9e11-5df5-66fbe8e334b6"},
+		{"vpn.home", "192.0.2.10", "/zones/11af3414-ebba-11e9-8df5-66fbe8a334b4/records/22af3414-abbe-9e11-5df5-66fbe8e334b7"},
+		{"vpn.home", "2001:db8::10", "/zones/11af3414-ebba-11e9-8df5-66fbe8a334b4/records/22af3414-abbe-9e11-5df5-66fbe8e334b8"},
+	}
+	for _, tt := range tests {
+		var updated []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/zones":
+				_, _ = w.Write([]byte(`[{"id":"11af3414-ebba-11e9-8df5-66fbe8a334b4","name":"example.com","type":"NATIVE"}]`))
+			case r.Method == http.MethodGet && r.URL.Path == "/zones/"+zone.ID:
+				filtered := zone
+				filtered.Records = nil
+				for _, rec := range zone.Records {
+					if rec.Name == r.URL.Query().Get("recordName") && rec.Type == r.URL.Query().Get("recordType") {
+						filtered.Records = append(filtered.Records, rec)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(filtered)
+			case r.Method == http.MethodPut:
+				updated = append(updated, r.URL.Path)
+			default:
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+		}))
+
+		provider := &DNSProvider{API: srv.URL + "/", client: srv.Client(), configuration: &settings.Settings{LoginToken: "prefix.secret"}}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if len(updated) != 1 || updated[0] != tt.path {
+			t.Errorf("%s (%s): expected only %s updated, got %v", tt.subdomain, tt.ip, tt.path, updated)
+		}
+		srv.Close()
+	}
+}
@@ -3,18 +3,35 @@ package linode
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/linode/linodego"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Linode"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Create: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 type DNSProvider struct {
-	linodeClient *linodego.Client
+	configuration *settings.Settings
+	linodeClient  *linodego.Client
 }
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
+	provider.configuration = conf
 	httpClient, err := CreateHTTPClient(conf)
 	if err != nil {
 		panic(err)
@@ -24,33 +41,97 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.linodeClient = &linodeAPIClient
 }
 
-func (provider *DNSProvider) UpdateIP(domain, subdomain, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domain, subdomain, ip string) error {
+	ttl := provider.configuration.GetRecordOptions(domain, subdomain).TTL
 	if subdomain == utils.RootDomain {
 		subdomain = ""
 	}
 
-	domainID, err := provider.getDomainID(domain)
+	domainID, err := provider.getDomainID(ctx, domain)
 	if err != nil {
 		return err
 	}
 
-	recordExists, recordID, err := provider.getDomainRecordID(domainID, subdomain)
+	recordType := utils.GetRecordType(ip)
+	recordExists, recordID, err := provider.getDomainRecordID(ctx, domainID, subdomain, recordType)
 	if err != nil {
 		return err
 	}
 	if !recordExists {
-		recordID, _ = provider.createDomainRecord(domainID, subdomain)
+		recordID, _ = provider.createDomainRecord(ctx, domainID, subdomain, recordType, ip, ttl)
+	}
+
+	err = provider.updateDomainRecord(ctx, domainID, recordID, ip, ttl)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domain string) ([]record.Record, error) {
+	domainID, err := provider.getDomainID(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := provider.linodeClient.ListDomainRecords(ctx, domainID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]record.Record, 0, len(res))
+	for _, rec := range res {
+		result = append(result, record.Record{
+			Name:  record.Hostname(domain, rec.Name),
+			Type:  string(rec.Type),
+			Value: rec.Target,
+			TTL:   rec.TTLSec,
+			ID:    strconv.Itoa(rec.ID),
+		})
+	}
+	return result, nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domain, subdomain, recordType string) (*record.Record, error) {
+	records, err := provider.GetRecords(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domain, subdomain), recordType), nil
+}
+
+// DeleteRecord deletes the record of the subdomain with the given type.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domain, subdomain, recordType, _ string) error {
+	hostname := record.Hostname(domain, subdomain)
+	if subdomain == utils.RootDomain {
+		subdomain = ""
 	}
 
-	err = provider.updateDomainRecord(domainID, recordID, ip)
+	domainID, err := provider.getDomainID(ctx, domain)
 	if err != nil {
 		return err
 	}
 
+	recordExists, recordID, err := provider.getDomainRecordID(ctx, domainID, subdomain, recordType)
+	if err != nil {
+		return err
+	}
+	if !recordExists {
+		log.Infof("Record %s (%s) already deleted", hostname, recordType)
+		return nil
+	}
+
+	if err := provider.linodeClient.DeleteDomainRecord(ctx, domainID, recordID); err != nil {
+		return err
+	}
+	log.Infof("Record deleted: %s (ID: %d)", hostname, recordID)
 	return nil
 }
 
-func (provider *DNSProvider) getDomainID(name string) (int, error) {
+func (provider *DNSProvider) getDomainID(ctx context.Context, name string) (int, error) {
 	f := linodego.Filter{}
 	f.AddField(linodego.Eq, "domain", name)
 	fStr, err := f.MarshalJSON()
@@ -59,7 +140,7 @@ func (provider *DNSProvider) getDomainID(name string) (int, error) {
 	}
 
 	opts := linodego.NewListOptions(0, string(fStr))
-	res, err := provider.linodeClient.ListDomains(context.Background(), opts)
+	res, err := provider.linodeClient.ListDomains(ctx, opts)
 	if err != nil {
 		return 0, err
 	}
@@ -69,8 +150,8 @@ func (provider *DNSProvider) getDomainID(name string) (int, error) {
 	return res[0].ID, nil
 }
 
-func (provider *DNSProvider) getDomainRecordID(domainID int, name string) (bool, int, error) {
-	res, err := provider.linodeClient.ListDomainRecords(context.Background(), domainID, nil)
+func (provider *DNSProvider) getDomainRecordID(ctx context.Context, domainID int, name, recordType string) (bool, int, error) {
+	res, err := provider.linodeClient.ListDomainRecords(ctx, domainID, nil)
 	if err != nil {
 		return false, 0, err
 	}
@@ -78,30 +159,35 @@ func (provider *DNSProvider) getDomainRecordID(domainID int, name string) (bool,
 		return false, 0, nil
 	}
 	for _, record := range res {
-		if record.Name == name {
+		if record.Name == name && string(record.Type) == recordType {
 			return true, record.ID, nil
 		}
 	}
 	return false, 0, nil
 }
 
-func (provider *DNSProvider) createDomainRecord(domainID int, name string) (int, error) {
+// createDomainRecord creates a record, with a TTL of 30 seconds if ttl is 0.
+func (provider *DNSProvider) createDomainRecord(ctx context.Context, domainID int, name, recordType, ip string, ttl int) (int, error) {
 	opts := &linodego.DomainRecordCreateOptions{
-		Type:   "A",
+		Type:   linodego.DomainRecordType(recordType),
 		Name:   name,
-		Target: "127.0.0.1",
+		Target: ip,
 		TTLSec: 30,
 	}
-	record, err := provider.linodeClient.CreateDomainRecord(context.Background(), domainID, *opts)
+	if ttl > 0 {
+		opts.TTLSec = ttl
+	}
+	record, err := provider.linodeClient.CreateDomainRecord(ctx, domainID, *opts)
 	if err != nil {
 		return 0, err
 	}
 	return record.ID, nil
 }
 
-func (provider *DNSProvider) updateDomainRecord(domainID int, id int, ip string) error {
-	opts := &linodego.DomainRecordUpdateOptions{Target: ip}
-	_, err := provider.linodeClient.UpdateDomainRecord(context.Background(), domainID, id, *opts)
+// updateDomainRecord updates the target of a record, and its TTL unless ttl is 0.
+func (provider *DNSProvider) updateDomainRecord(ctx context.Context, domainID int, id int, ip string, ttl int) error {
+	opts := &linodego.DomainRecordUpdateOptions{Target: ip, TTLSec: ttl}
+	_, err := provider.linodeClient.UpdateDomainRecord(ctx, domainID, id, *opts)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,118 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/linode/linodego"
+)
+
+// newMockLinode serves the domain example.com with the records of testdata/nested_records.json.
+func newMockLinode(t *testing.T, handle func(w http.ResponseWriter, r *http.Request)) *DNSProvider {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v4/domains":
+			_, _ = w.Write([]byte(`{"data":[{"id":1234,"domain":"example.com"}],"page":1,"pages":1,"results":1}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v4/domains/1234/records":
+			_, _ = w.Write(fixture)
+		default:
+			handle(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := linodego.NewClient(srv.Client())
+	client.SetBaseURL(srv.URL)
+	return &DNSProvider{
+		configuration: &settings.Settings{LoginToken: "test-token"},
+		linodeClient:  &client,
+	}
+}
+
+// Wildcard and nested subdomains only match their own record, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	tests := []struct {
+		subdomain string
+		ip        string
+		path      string
+	}{
+		{"@", "192.0.2.10", "/v4/domains/1234/records/28448429"},
+		{"home", "192.0.2.10", "/v4/domains/1234/records/28448430"},
+		{"*.home", "192.0.2.10", "/v4/domains/1234/records/28448431"},
+		{"vpn.home", "192.0.2.10", "/v4/domains/1234/records/28448432"},
+		{"vpn.home", "2001:db8::10", "/v4/domains/1234/records/28448433"},
+	}
+	for _, tt := range tests {
+		var updated []string
+		provider := newMockLinode(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+			updated = append(updated, r.URL.Path)
+			_, _ = w.Write([]byte(`{}`))
+		})
+
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if !slices.Equal(updated, []string{tt.path}) {
+			t.Errorf("%s (%s): expected only %s updated, got %v", tt.subdomain, tt.ip, tt.path, updated)
+		}
+	}
+}
+
+// A missing nested record is created with its name relative to the domain.
+func TestCreateNestedRecord(t *testing.T) {
+	var created linodego.DomainRecordCreateOptions
+	provider := newMockLinode(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"id":28448434}`))
+		case http.MethodPut:
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+	})
+
+	if err := provider.UpdateIP(context.Background(), "example.com", "*.vpn.home", "192.0.2.10"); err != nil {
+		t.Fatalf("UpdateIP failed: %v", err)
+	}
+	if created.Name != "*.vpn.home" || created.Type != "A" || created.Target != "192.0.2.10" {
+		t.Errorf("unexpected record created: %+v", created)
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	var deleted []string
+	provider := newMockLinode(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+		}
+		deleted = append(deleted, r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	if err := provider.DeleteRecord(context.Background(), "example.com", "*.home", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	if err := provider.DeleteRecord(context.Background(), "example.com", "missing", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord of a missing record failed: %v", err)
+	}
+	if !slices.Equal(deleted, []string{"/v4/domains/1234/records/28448431"}) {
+		t.Errorf("expected only record 28448431 deleted, got %v", deleted)
+	}
+}
@@ -1,16 +1,38 @@
 package loopiase
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "LoopiaSE"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		Email:        true,
+		Password:     true,
+		Capabilities: registry.Capabilities{IPv6: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+		Validate: func(credentials registry.Credentials) error {
+			if credentials.Password == "" {
+				return errors.New("password cannot be empty")
+			}
+			return nil
+		},
+	})
+}
+
 const (
 	// URL the API address for LoopiaSE.
 	URL = "https://%s:%s@dyndns.loopia.se/?hostname=%s.%s&myip=%s"
@@ -26,20 +48,24 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
 }
 
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
-	return provider.updateIP(domainName, subdomainName, ip)
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	return provider.updateIP(ctx, domainName, subdomainName, ip)
 }
 
 // updateIP update subdomain with current IP.
-func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
+func (provider *DNSProvider) updateIP(ctx context.Context, domain, subDomain, currentIP string) error {
 	client := utils.GetHTTPClient(provider.configuration)
-	resp, err := client.Get(fmt.Sprintf(URL,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(URL,
 		provider.configuration.Email,
 		provider.configuration.Password,
 		subDomain,
 		domain,
-		currentIP))
+		currentIP), nil)
+	if err != nil {
+		return err
+	}
 
+	resp, err := client.Do(req)
 	if err != nil {
 		// handle error
 		log.Error("Failed to update sub domain:", subDomain)
@@ -52,6 +78,9 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 			log.Error(err)
 		}
 	}(resp.Body)
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
 
 	if err != nil {
 		log.Error("Err:", err.Error())
@@ -1,21 +1,41 @@
 package ovh
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"net/url"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/ovh/go-ovh/ovh"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "OVH"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		AppKey:       true,
+		AppSecret:    true,
+		ConsumerKey:  true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 type DNSProvider struct {
 	configuration *settings.Settings
+	// API is the endpoint of the OVH API, either a name like ovh-eu or a URL.
+	API string
 }
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
+	provider.API = ovh.OvhEU
 }
 
 type Record struct {
@@ -27,9 +47,9 @@ type Record struct {
 	ID        int    `json:"id"`
 }
 
-func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName string, subdomainName string, ip string) error {
 	client, err := ovh.NewClient(
-		"ovh-eu",
+		provider.API,
 		provider.configuration.AppKey,
 		provider.configuration.AppSecret,
 		provider.configuration.ConsumerKey,
@@ -39,12 +59,17 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 		return err
 	}
 	var IDs []int
-	query := fmt.Sprintf("/domain/zone/%s/record?subDomain=%s", domainName, subdomainName)
+	// the zone itself has an empty subdomain, a wildcard is escaped as %2A
+	subDomain := subdomainName
+	if subDomain == utils.RootDomain {
+		subDomain = ""
+	}
+	query := fmt.Sprintf("/domain/zone/%s/record?subDomain=%s", domainName, url.QueryEscape(subDomain))
 
-	err = client.Get(query, &IDs)
+	err = client.GetWithContext(ctx, query, &IDs)
 	if err != nil {
 		log.Error("Fetch error")
-		return err
+		return checkRateLimit(err)
 	}
 	if len(IDs) < 1 {
 		log.Error("No matching records")
@@ -54,13 +79,13 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 
 	for _, id := range IDs {
 		record := Record{}
-		err = client.Get(fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(id)), &record)
+		err = client.GetWithContext(ctx, fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(id)), &record)
 		if err != nil {
 			log.Error("Fetch error on get record: ", id)
-			return err
+			return checkRateLimit(err)
 		}
 
-		if strings.ToUpper(provider.configuration.IPType) == provider.recordTypeToIPType(record.Type) {
+		if utils.GetIPType(ip) == provider.recordTypeToIPType(record.Type) {
 			outrec = record
 			break
 		}
@@ -71,19 +96,31 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 	}
 	outrec.Value = ip
 	// Update IP.
-	err = client.Put(fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(outrec.ID)), outrec, nil)
+	err = client.PutWithContext(ctx, fmt.Sprintf("/domain/zone/%s/record/%s", domainName, fmt.Sprint(outrec.ID)), outrec, nil)
 	if err != nil {
 		log.Error("Error while Updating record: ", outrec.SubDomain, outrec.Zone)
-		return err
+		return checkRateLimit(err)
 	}
 	// Refresh zone.
-	err = client.Post(fmt.Sprintf("/domain/zone/%s/refresh", domainName), nil, nil)
+	err = client.PostWithContext(ctx, fmt.Sprintf("/domain/zone/%s/refresh", domainName), nil, nil)
 	if err != nil {
 		log.Error("Applying new records failed")
-		return err
+		return checkRateLimit(err)
 	}
 	return nil
 }
+
+// checkRateLimit wraps the API errors with a 429 or 503 status in a RetryAfterError.
+func checkRateLimit(err error) error {
+	var apiErr *ovh.APIError
+	if errors.As(err, &apiErr) {
+		if rateErr := utils.CheckStatusCode(apiErr.Code); rateErr != nil {
+			return fmt.Errorf("%w: %v", rateErr, err)
+		}
+	}
+	return err
+}
+
 func (provider *DNSProvider) recordTypeToIPType(Type string) string {
 	if Type == utils.IPTypeAAAA {
 		return utils.IPV6
@@ -0,0 +1,87 @@
+package ovh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains are looked up by their name relative to the
+// zone, see testdata/nested_records.json. The records are filtered like the API does.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err := json.Unmarshal(fixture, &records); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdomain string
+		ip        string
+		id        int
+	}{
+		{"@", "192.0.2.10", 5131474201},
+		{"home", "192.0.2.10", 5131474202},
+		{"*.home", "192.0.2.10", 5131474203},
+		{"vpn.home", "192.0.2.10", 5131474204},
+		{"vpn.home", "2001:db8::10", 5131474205},
+	}
+	for _, tt := range tests {
+		var updated []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/auth/time":
+				_, _ = fmt.Fprint(w, time.Now().Unix())
+			case r.Method == http.MethodGet && r.URL.Path == "/domain/zone/example.com/record":
+				ids := []int{}
+				for _, rec := range records {
+					if rec.SubDomain == r.URL.Query().Get("subDomain") {
+						ids = append(ids, rec.ID)
+					}
+				}
+				_ = json.NewEncoder(w).Encode(ids)
+			case r.Method == http.MethodGet:
+				for _, rec := range records {
+					if r.URL.Path == fmt.Sprintf("/domain/zone/example.com/record/%d", rec.ID) {
+						_ = json.NewEncoder(w).Encode(rec)
+						return
+					}
+				}
+				http.NotFound(w, r)
+			case r.Method == http.MethodPut:
+				var rec Record
+				_ = json.NewDecoder(r.Body).Decode(&rec)
+				updated = append(updated, fmt.Sprintf("%s %d=%s", r.URL.Path, rec.ID, rec.Value))
+				_, _ = w.Write([]byte(`null`))
+			case r.Method == http.MethodPost && r.URL.Path == "/domain/zone/example.com/refresh":
+				_, _ = w.Write([]byte(`null`))
+			default:
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+		}))
+
+		provider := &DNSProvider{
+			API:           srv.URL,
+			configuration: &settings.Settings{AppKey: "key", AppSecret: "secret", ConsumerKey: "consumer"},
+		}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s (%s) failed: %v", tt.subdomain, tt.ip, err)
+		}
+		want := fmt.Sprintf("/domain/zone/example.com/record/%d %d=%s", tt.id, tt.id, tt.ip)
+		if !slices.Equal(updated, []string{want}) {
+			t.Errorf("%s (%s): expected only %s, got %v", tt.subdomain, tt.ip, want, updated)
+		}
+		srv.Close()
+	}
+}
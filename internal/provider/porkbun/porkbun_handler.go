@@ -2,17 +2,44 @@ package porkbun
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/record"
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Porkbun"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Password:     true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Create: true, Delete: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+		Validate: func(credentials registry.Credentials) error {
+			if credentials.LoginToken == "" {
+				return errors.New("API key cannot be empty")
+			}
+			if credentials.Password == "" {
+				return errors.New("secret key cannot be empty")
+			}
+			return nil
+		},
+	})
+}
+
 const (
 	// URL is the endpoint for the Porkbun API.
 	URL = "https://api.porkbun.com/api/json/v3"
@@ -78,21 +105,18 @@ func (provider *DNSProvider) Init(conf *settings.Settings) {
 }
 
 // UpdateIP updates the DNS record for the given domain and subdomain.
-func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
 	log.Infof("Checking IP for domain %s.%s", subdomainName, domainName)
 
 	// Get existing records
-	records, err := provider.getRecords(domainName)
+	records, err := provider.getRecords(ctx, domainName)
 	if err != nil {
 		log.Errorf("Failed to get DNS records for domain %s: %v", domainName, err)
 		return err
 	}
 
 	// Determine record type
-	recordType := utils.IPTypeA
-	if provider.configuration.IPType != "" && strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		recordType = utils.IPTypeAAAA
-	}
+	recordType := utils.GetRecordType(ip)
 
 	// Find the target record name
 	var targetName string
@@ -104,9 +128,9 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 	// Check if record exists and needs updating
 	var existingRecord *Record
-	for _, record := range records {
-		if record.Name == targetName && record.Type == recordType {
-			existingRecord = &record
+	for _, rec := range records {
+		if rec.Name == targetName && rec.Type == recordType {
+			existingRecord = &rec
 			break
 		}
 	}
@@ -120,7 +144,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 
 		// Update existing record
 		log.Infof("IP mismatch: Current(%s) vs Porkbun(%s)", ip, existingRecord.Content)
-		if err := provider.editRecord(domainName, existingRecord.ID, targetName, recordType, ip); err != nil {
+		if err := provider.editRecord(ctx, domainName, existingRecord.ID, targetName, recordType, ip, provider.recordTTL(domainName, subdomainName)); err != nil {
 			log.Errorf("Failed to update DNS record: %v", err)
 			return err
 		}
@@ -128,7 +152,7 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 	} else {
 		// Record doesn't exist, create it
 		log.Debugf("Record %s not found, will create it.", targetName)
-		if err := provider.createRecord(domainName, targetName, recordType, ip); err != nil {
+		if err := provider.createRecord(ctx, domainName, targetName, recordType, ip, provider.recordTTL(domainName, subdomainName)); err != nil {
 			log.Errorf("Failed to create DNS record: %v", err)
 			return err
 		}
@@ -139,7 +163,53 @@ func (provider *DNSProvider) UpdateIP(domainName, subdomainName, ip string) erro
 }
 
 // getRecords retrieves all DNS records for a domain.
-func (provider *DNSProvider) getRecords(domain string) ([]Record, error) {
+// GetRecords lists the records of the domain.
+func (provider *DNSProvider) GetRecords(ctx context.Context, domainName string) ([]record.Record, error) {
+	records, err := provider.getRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]record.Record, 0, len(records))
+	for _, rec := range records {
+		ttl, _ := strconv.Atoi(rec.TTL)
+		result = append(result, record.Record{Name: rec.Name, Type: rec.Type, Value: rec.Content, TTL: ttl, ID: rec.ID})
+	}
+	return result, nil
+}
+
+// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+func (provider *DNSProvider) GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error) {
+	records, err := provider.GetRecords(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+	return record.Find(records, record.Hostname(domainName, subdomainName), recordType), nil
+}
+
+// DeleteRecord deletes the record of the subdomain with the given type.
+func (provider *DNSProvider) DeleteRecord(ctx context.Context, domainName, subdomainName, recordType, _ string) error {
+	rec, err := provider.GetRecord(ctx, domainName, subdomainName, recordType)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		log.Infof("Record %s (%s) already deleted", record.Hostname(domainName, subdomainName), recordType)
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/dns/delete/%s/%s", provider.API, domainName, rec.ID)
+	if err := provider.post(ctx, url, APIRequest{
+		SecretAPIKey: provider.configuration.Password,
+		APIKey:       provider.configuration.LoginToken,
+	}); err != nil {
+		return err
+	}
+	log.Infof("Record deleted: %s (ID: %s)", rec.Name, rec.ID)
+	return nil
+}
+
+func (provider *DNSProvider) getRecords(ctx context.Context, domain string) ([]Record, error) {
 	reqBody := APIRequest{
 		SecretAPIKey: provider.configuration.Password,
 		APIKey:       provider.configuration.LoginToken,
@@ -156,7 +226,7 @@ func (provider *DNSProvider) getRecords(domain string) ([]Record, error) {
 	}
 
 	url := fmt.Sprintf("%s/dns/retrieve/%s", provider.API, domain)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -168,6 +238,10 @@ func (provider *DNSProvider) getRecords(domain string) ([]Record, error) {
 	}
 	defer resp.Body.Close()
 
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return nil, err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %v", err)
@@ -185,8 +259,16 @@ func (provider *DNSProvider) getRecords(domain string) ([]Record, error) {
 	return response.Records, nil
 }
 
+// recordTTL returns the configured TTL of a record, 600 seconds by default.
+func (provider *DNSProvider) recordTTL(domainName, subdomainName string) string {
+	if ttl := provider.configuration.GetRecordOptions(domainName, subdomainName).TTL; ttl > 0 {
+		return strconv.Itoa(ttl)
+	}
+	return "600"
+}
+
 // createRecord creates a new DNS record.
-func (provider *DNSProvider) createRecord(domain, name, recordType, content string) error {
+func (provider *DNSProvider) createRecord(ctx context.Context, domain, name, recordType, content, ttl string) error {
 	// For Porkbun API, we need just the subdomain part for the name field
 	recordName := ""
 	if name != domain {
@@ -201,51 +283,14 @@ func (provider *DNSProvider) createRecord(domain, name, recordType, content stri
 		Name:    recordName,
 		Type:    recordType,
 		Content: content,
-		TTL:     "600", // Default TTL
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %v", err)
+		TTL:     ttl,
 	}
 
-	client := utils.GetHTTPClient(provider.configuration)
-	if client == nil {
-		return fmt.Errorf("cannot create HTTP client")
-	}
-
-	url := fmt.Sprintf("%s/dns/create/%s", provider.API, domain)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
-	}
-
-	var response APIResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	if response.Status != "SUCCESS" {
-		return fmt.Errorf("API returned error status: %s", response.Status)
-	}
-
-	return nil
+	return provider.post(ctx, fmt.Sprintf("%s/dns/create/%s", provider.API, domain), reqBody)
 }
 
 // editRecord updates an existing DNS record.
-func (provider *DNSProvider) editRecord(domain, recordID, name, recordType, content string) error {
+func (provider *DNSProvider) editRecord(ctx context.Context, domain, recordID, name, recordType, content, ttl string) error {
 	// For Porkbun API, we need just the subdomain part for the name field
 	recordName := ""
 	if name != domain {
@@ -260,9 +305,14 @@ func (provider *DNSProvider) editRecord(domain, recordID, name, recordType, cont
 		Name:    recordName,
 		Type:    recordType,
 		Content: content,
-		TTL:     "600", // Default TTL
+		TTL:     ttl,
 	}
 
+	return provider.post(ctx, fmt.Sprintf("%s/dns/edit/%s/%s", provider.API, domain, recordID), reqBody)
+}
+
+// post sends a request to the API and checks the status of its response.
+func (provider *DNSProvider) post(ctx context.Context, url string, reqBody any) error {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %v", err)
@@ -273,8 +323,7 @@ func (provider *DNSProvider) editRecord(domain, recordID, name, recordType, cont
 		return fmt.Errorf("cannot create HTTP client")
 	}
 
-	url := fmt.Sprintf("%s/dns/edit/%s/%s", provider.API, domain, recordID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %v", err)
 	}
@@ -286,6 +335,10 @@ func (provider *DNSProvider) editRecord(domain, recordID, name, recordType, cont
 	}
 	defer resp.Body.Close()
 
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response: %v", err)
@@ -0,0 +1,86 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains are sent relative to the domain, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdomain string
+		ip        string
+		path      string
+		name      string
+	}{
+		{"*.home", "192.0.2.10", "/dns/edit/example.com/106926653", "*.home"},
+		{"vpn.home", "2001:db8::10", "/dns/edit/example.com/106926654", "vpn.home"},
+		// the A record of vpn.home is missing
+		{"vpn.home", "192.0.2.10", "/dns/create/example.com", "vpn.home"},
+		{"@", "192.0.2.10", "/dns/create/example.com", ""},
+	}
+	for _, tt := range tests {
+		var path string
+		var request EditRecordRequest
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/dns/retrieve/example.com" {
+				_, _ = w.Write(fixture)
+				return
+			}
+			path = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			_, _ = w.Write([]byte(`{"status":"SUCCESS"}`))
+		}))
+
+		provider := &DNSProvider{API: srv.URL, configuration: &settings.Settings{LoginToken: "key", Password: "secret"}}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if path != tt.path || request.Name != tt.name {
+			t.Errorf("%s (%s): expected %s with name %q, got %s with name %q", tt.subdomain, tt.ip, tt.path, tt.name, path, request.Name)
+		}
+		srv.Close()
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dns/retrieve/example.com" {
+			_, _ = w.Write(fixture)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		_, _ = w.Write([]byte(`{"status":"SUCCESS"}`))
+	}))
+	defer srv.Close()
+
+	provider := &DNSProvider{API: srv.URL, configuration: &settings.Settings{LoginToken: "key", Password: "secret"}}
+	if err := provider.DeleteRecord(context.Background(), "example.com", "*.home", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	// the A record of vpn.home is already gone
+	if err := provider.DeleteRecord(context.Background(), "example.com", "vpn.home", "A", ""); err != nil {
+		t.Fatalf("DeleteRecord of a missing record failed: %v", err)
+	}
+	if !slices.Equal(deleted, []string{"/dns/delete/example.com/106926653"}) {
+		t.Errorf("expected only record 106926653 deleted, got %v", deleted)
+	}
+}
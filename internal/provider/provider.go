@@ -1,10 +1,72 @@
 package provider
 
 import (
+	"context"
+
+	"github.com/TimothyYe/godns/internal/provider/record"
 	"github.com/TimothyYe/godns/internal/settings"
 )
 
+// IDNSProvider is the legacy provider interface, whose calls can't be cancelled.
+// Use AdaptLegacy to run an implementation as an IDNSProviderV2.
 type IDNSProvider interface {
 	Init(conf *settings.Settings)
 	UpdateIP(domainName, subdomainName, ip string) error
 }
+
+// IDNSProviderV2 is the provider interface: the calls stop as soon as their context is done.
+type IDNSProviderV2 interface {
+	Init(conf *settings.Settings)
+	UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error
+}
+
+// IRecordReader is implemented by the providers able to read back their records
+// through their API, which is preferred over a DNS lookup to know the current
+// value of a record.
+type IRecordReader interface {
+	// GetRecords lists the records of the domain.
+	GetRecords(ctx context.Context, domainName string) ([]record.Record, error)
+	// GetRecord returns the record of the subdomain with the given type, or nil if it doesn't exist.
+	GetRecord(ctx context.Context, domainName, subdomainName, recordType string) (*record.Record, error)
+}
+
+// IRecordDeleter is implemented by the providers able to delete records, used
+// to clean up the records removed from the configuration.
+type IRecordDeleter interface {
+	// DeleteRecord deletes the record of the subdomain with the given type. The
+	// value is the last one set by GoDNS, required by some provider APIs.
+	DeleteRecord(ctx context.Context, domainName, subdomainName, recordType, value string) error
+}
+
+// AdaptLegacy wraps a legacy provider into an IDNSProviderV2. A legacy call
+// can't be interrupted: when the context is done the adapter returns right
+// away, and the call finishes in the background.
+func AdaptLegacy(provider IDNSProvider) IDNSProviderV2 {
+	return &legacyProvider{provider: provider}
+}
+
+type legacyProvider struct {
+	provider IDNSProvider
+}
+
+func (p *legacyProvider) Init(conf *settings.Settings) {
+	p.provider.Init(conf)
+}
+
+func (p *legacyProvider) UpdateIP(ctx context.Context, domainName, subdomainName, ip string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- p.provider.UpdateIP(domainName, subdomainName, ip)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/provider/alidns"
+	"github.com/TimothyYe/godns/internal/provider/cloudflare"
+	"github.com/TimothyYe/godns/internal/provider/digitalocean"
+	"github.com/TimothyYe/godns/internal/provider/dnspod"
+	"github.com/TimothyYe/godns/internal/provider/duck"
+	"github.com/TimothyYe/godns/internal/provider/hetzner"
+	"github.com/TimothyYe/godns/internal/provider/linode"
+	"github.com/TimothyYe/godns/internal/provider/porkbun"
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// legacyTestProvider implements the legacy interface, blocking until release is closed.
+type legacyTestProvider struct {
+	release chan struct{}
+	calls   []string
+}
+
+func (p *legacyTestProvider) Init(_ *settings.Settings) {}
+
+func (p *legacyTestProvider) UpdateIP(domainName, subdomainName, ip string) error {
+	<-p.release
+	p.calls = append(p.calls, subdomainName+"."+domainName+"="+ip)
+	return nil
+}
+
+func TestAdaptLegacy(t *testing.T) {
+	legacy := &legacyTestProvider{release: make(chan struct{})}
+	adapted := AdaptLegacy(legacy)
+
+	close(legacy.release)
+	if err := adapted.UpdateIP(context.Background(), "example.com", "www", "1.2.3.4"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(legacy.calls) != 1 || legacy.calls[0] != "www.example.com=1.2.3.4" {
+		t.Errorf("expected the call to be forwarded, got %v", legacy.calls)
+	}
+
+	// a hanging legacy call is abandoned when the context is done
+	hanging := AdaptLegacy(&legacyTestProvider{release: make(chan struct{})})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := hanging.UpdateIP(ctx, "example.com", "www", "1.2.3.4"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to be abandoned, got %v", err)
+	}
+}
+
+func TestRecordReaders(t *testing.T) {
+	readers := []string{cloudflare.Name, hetzner.Name, porkbun.Name, digitalocean.Name, linode.Name, dnspod.Name, alidns.Name}
+	conf := &settings.Settings{LoginToken: "test-token"}
+
+	for _, name := range readers {
+		dnsProvider, err := createProvider(name, conf)
+		if err != nil {
+			t.Fatalf("failed to create %s: %s", name, err)
+		}
+		if _, ok := dnsProvider.(IRecordReader); !ok {
+			t.Errorf("expected %s to read back its records", name)
+		}
+	}
+
+	dnsProvider, err := createProvider(duck.Name, conf)
+	if err != nil {
+		t.Fatalf("failed to create %s: %s", duck.Name, err)
+	}
+	if _, ok := dnsProvider.(IRecordReader); ok {
+		t.Errorf("expected %s not to read back its records", duck.Name)
+	}
+}
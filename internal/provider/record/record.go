@@ -0,0 +1,58 @@
+// Package record defines the DNS records read back from the provider APIs.
+package record
+
+import (
+	"strings"
+
+	"github.com/TimothyYe/godns/internal/utils"
+)
+
+// OwnerMarker marks the records managed by GoDNS, in the comment of the records
+// of the providers supporting comments.
+const OwnerMarker = "heritage=godns"
+
+// Record is a DNS record as stored by a provider.
+type Record struct {
+	// Name is the fully qualified name of the record, e.g. www.example.com.
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   int    `json:"ttl"`
+	// ID identifies the record at the provider.
+	ID string `json:"id"`
+}
+
+// Hostname returns the fully qualified name of a record of the domain, from
+// its name relative to the domain: "@" or an empty name is the domain itself.
+func Hostname(domainName, name string) string {
+	if name == "" || name == utils.RootDomain {
+		return domainName
+	}
+	return name + "." + domainName
+}
+
+// IsOwned returns true if the comment of a record holds the owner marker.
+func IsOwned(comment string) bool {
+	return strings.Contains(comment, OwnerMarker)
+}
+
+// MarkOwned appends the owner marker to the comment of a record, if missing.
+func MarkOwned(comment string) string {
+	if IsOwned(comment) {
+		return comment
+	}
+	if comment == "" {
+		return OwnerMarker
+	}
+	return comment + " " + OwnerMarker
+}
+
+// Find returns the first record with the given name and type, or nil.
+func Find(records []Record, name, recordType string) *Record {
+	for i := range records {
+		if records[i].Name == name && records[i].Type == recordType {
+			return &records[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package record
+
+import "testing"
+
+func TestHostname(t *testing.T) {
+	tests := map[string]string{
+		"":    "example.com",
+		"@":   "example.com",
+		"www": "www.example.com",
+		"a.b": "a.b.example.com",
+		"*.b": "*.b.example.com",
+	}
+
+	for name, want := range tests {
+		if got := Hostname("example.com", name); got != want {
+			t.Errorf("Hostname(%q): expected %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	records := []Record{
+		{Name: "www.example.com", Type: "AAAA", Value: "2001:db8::1"},
+		{Name: "www.example.com", Type: "A", Value: "192.0.2.1"},
+	}
+
+	if rec := Find(records, "www.example.com", "A"); rec == nil || rec.Value != "192.0.2.1" {
+		t.Errorf("expected the A record, got %+v", rec)
+	}
+	if rec := Find(records, "example.com", "A"); rec != nil {
+		t.Errorf("expected no record, got %+v", rec)
+	}
+}
+
+func TestMarkOwned(t *testing.T) {
+	if IsOwned("set by hand") {
+		t.Error("expected a record without the marker not to be owned")
+	}
+
+	for _, comment := range []string{"", "home", OwnerMarker} {
+		marked := MarkOwned(comment)
+		if !IsOwned(marked) || MarkOwned(marked) != marked {
+			t.Errorf("unexpected marked comment of %q: %q", comment, marked)
+		}
+	}
+	if MarkOwned("home") != "home "+OwnerMarker {
+		t.Errorf("expected the comment to be kept, got %q", MarkOwned("home"))
+	}
+}
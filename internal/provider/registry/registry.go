@@ -0,0 +1,160 @@
+// Package registry holds the supported DNS providers. Each provider package
+// registers itself from its init function, with its constructor, the
+// credentials it expects and its capabilities.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Instance is a provider instance, implementing one of the provider interfaces.
+type Instance interface {
+	Init(conf *settings.Settings)
+}
+
+// Provider describes a supported DNS provider. The credential fields list the
+// credentials shown by the web panel, which are all required unless Validate
+// is set.
+type Provider struct {
+	Name         string       `json:"name" yaml:"name"`
+	Username     bool         `json:"username" yaml:"username"`
+	Email        bool         `json:"email" yaml:"email"`
+	Password     bool         `json:"password" yaml:"password"`
+	LoginToken   bool         `json:"login_token" yaml:"login_token"`
+	AppKey       bool         `json:"app_key" yaml:"app_key"`
+	AppSecret    bool         `json:"app_secret" yaml:"app_secret"`
+	ConsumerKey  bool         `json:"consumer_key" yaml:"consumer_key"`
+	Capabilities Capabilities `json:"capabilities" yaml:"capabilities"`
+
+	// New returns a new provider instance, initialized by the caller.
+	New func() Instance `json:"-" yaml:"-"`
+	// Validate checks the credentials of a configuration of the provider.
+	Validate func(credentials Credentials) error `json:"-" yaml:"-"`
+}
+
+// Capabilities describes what GoDNS can do with the records of a provider.
+type Capabilities struct {
+	// IPv6 is true if AAAA records can be updated.
+	IPv6 bool `json:"ipv6" yaml:"ipv6"`
+	// TTL is true if the TTL of the records can be set.
+	TTL bool `json:"ttl" yaml:"ttl"`
+	// Proxied is true if the records can be proxied by the provider.
+	Proxied bool `json:"proxied" yaml:"proxied"`
+	// Comment is true if a comment can be attached to the records.
+	Comment bool `json:"comment" yaml:"comment"`
+	// Ownership is true if the records managed by GoDNS are marked, so that the
+	// records managed by hand are never modified unless adopted.
+	Ownership bool `json:"ownership" yaml:"ownership"`
+	// Zone is true if the provider hosts whole zones, shared with the records
+	// managed by hand. Without Ownership, GoDNS only modifies the records it
+	// created or adopted, according to its state.
+	Zone bool `json:"zone" yaml:"zone"`
+	// Create is true if the missing records are created, instead of failing the update.
+	Create bool `json:"create" yaml:"create"`
+	// Delete is true if records can be deleted.
+	Delete bool `json:"delete" yaml:"delete"`
+	// MultipleValues is true if a name can hold several records of the same type.
+	MultipleValues bool `json:"multiple_values" yaml:"multiple_values"`
+	// TXT is true if TXT records can be updated.
+	TXT bool `json:"txt" yaml:"txt"`
+	// Wildcard is true if wildcard subdomains, e.g. *.home, can be updated.
+	Wildcard bool `json:"wildcard" yaml:"wildcard"`
+}
+
+// Credentials holds the credentials of a provider configuration.
+type Credentials struct {
+	Email       string
+	Password    string
+	LoginToken  string
+	AppKey      string
+	AppSecret   string
+	ConsumerKey string
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+// Register adds a provider to the registry. It panics if the provider is
+// incomplete or already registered, like a duplicate provider package would.
+func Register(provider Provider) {
+	if provider.Name == "" || provider.New == nil {
+		panic("registry: a provider needs a name and a constructor")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := providers[provider.Name]; exists {
+		panic(fmt.Sprintf("registry: provider %s registered twice", provider.Name))
+	}
+	providers[provider.Name] = provider
+}
+
+// Get returns a registered provider by name.
+func Get(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	provider, exists := providers[name]
+	return provider, exists
+}
+
+// Providers returns the registered providers, sorted by name.
+func Providers() []Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Provider, 0, len(providers))
+	for _, provider := range providers {
+		list = append(list, provider)
+	}
+	slices.SortFunc(list, func(a, b Provider) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list
+}
+
+// ValidateCredentials checks the credentials of a configuration of the named provider.
+func ValidateCredentials(name string, credentials Credentials) error {
+	provider, exists := Get(name)
+	if !exists {
+		return fmt.Errorf("'%s' is not a supported DNS provider", name)
+	}
+
+	if provider.Validate != nil {
+		return provider.Validate(credentials)
+	}
+	return provider.requireCredentials(credentials)
+}
+
+// requireCredentials checks that the credentials listed by the provider are set.
+func (p *Provider) requireCredentials(credentials Credentials) error {
+	required := []struct {
+		needed bool
+		value  string
+		name   string
+	}{
+		{p.Email, credentials.Email, "email"},
+		{p.Password, credentials.Password, "password"},
+		{p.LoginToken, credentials.LoginToken, "login token"},
+		{p.AppKey, credentials.AppKey, "app key"},
+		{p.AppSecret, credentials.AppSecret, "app secret"},
+		{p.ConsumerKey, credentials.ConsumerKey, "consumer key"},
+	}
+
+	for _, credential := range required {
+		if credential.needed && credential.value == "" {
+			return errors.New(credential.name + " cannot be empty")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+type testInstance struct{}
+
+func (i *testInstance) Init(_ *settings.Settings) {}
+
+func TestValidateCredentials(t *testing.T) {
+	Register(Provider{
+		Name:       "RegistryTest",
+		LoginToken: true,
+		AppKey:     true,
+		New:        func() Instance { return &testInstance{} },
+	})
+
+	if _, exists := Get("RegistryTest"); !exists {
+		t.Fatal("expected the provider to be registered")
+	}
+
+	// the credentials listed by the provider are required by default
+	if err := ValidateCredentials("RegistryTest", Credentials{LoginToken: "token"}); err == nil || err.Error() != "app key cannot be empty" {
+		t.Errorf("expected the app key to be required, got %v", err)
+	}
+	if err := ValidateCredentials("RegistryTest", Credentials{LoginToken: "token", AppKey: "key"}); err != nil {
+		t.Errorf("expected the credentials to be valid, got %v", err)
+	}
+
+	if err := ValidateCredentials("Unknown", Credentials{}); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a duplicate registration to panic")
+		}
+	}()
+	Register(Provider{Name: "RegistryTest", New: func() Instance { return &testInstance{} }})
+}
@@ -2,18 +2,31 @@ package scaleway
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
+// Name is the name of the provider in the configuration.
+const Name = "Scaleway"
+
+func init() {
+	registry.Register(registry.Provider{
+		Name:         Name,
+		LoginToken:   true,
+		Capabilities: registry.Capabilities{IPv6: true, Zone: true, TTL: true, Comment: true, Create: true, Wildcard: true},
+		New:          func() registry.Instance { return &DNSProvider{} },
+	})
+}
+
 const (
 	URL = "https://api.scaleway.com/domain/v2beta1/dns-zones/%s/records"
 )
@@ -21,6 +34,8 @@ const (
 // DNSProvider struct.
 type DNSProvider struct {
 	configuration *settings.Settings
+	// API is the URL of the records of a zone, formatted with the zone name.
+	API string
 }
 
 // Record for Scaleway API.
@@ -55,11 +70,12 @@ type DNSUpdateRequest struct {
 
 func (provider *DNSProvider) Init(conf *settings.Settings) {
 	provider.configuration = conf
+	provider.API = URL
 }
 
-func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, ip string) error {
+func (provider *DNSProvider) UpdateIP(ctx context.Context, domainName string, subdomainName string, ip string) error {
 	log.Infof("%s.%s - Start to update record IP...", subdomainName, domainName)
-	err := provider.updateIP(domainName, subdomainName, ip)
+	err := provider.updateIP(ctx, domainName, subdomainName, ip)
 	if err != nil {
 		log.Error(err)
 		return err
@@ -68,33 +84,35 @@ func (provider *DNSProvider) UpdateIP(domainName string, subdomainName string, i
 	return nil
 }
 
-func (provider *DNSProvider) getRecordType() (string, error) {
-	if strings.ToUpper(provider.configuration.IPType) == utils.IPV4 {
-		return utils.IPTypeA, nil
-	} else if strings.ToUpper(provider.configuration.IPType) == utils.IPV6 {
-		return utils.IPTypeAAAA, nil
+// updateIP update subdomain with current IP.
+func (provider *DNSProvider) updateIP(ctx context.Context, domain, subDomain, currentIP string) error {
+	recordType := utils.GetRecordType(currentIP)
+	options := provider.configuration.GetRecordOptions(domain, subDomain)
+	ttl, comment := provider.configuration.Interval, "Set by GoDNS"
+	if options.TTL > 0 {
+		ttl = options.TTL
+	}
+	if options.Comment != "" {
+		comment = options.Comment
 	}
-	return "", errors.New("must specify \"ip_type\" in config for Scaleway")
-}
 
-// updateIP update subdomain with current IP.
-func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error {
-	recordType, err := provider.getRecordType()
-	if err != nil {
-		return err
+	// the names are relative to the zone, whose own name is empty
+	name := subDomain
+	if name == utils.RootDomain {
+		name = ""
 	}
 
 	reqBody := DNSUpdateRequest{Changes: []DNSChange{{SetRecord{
 		IDFields: IDFields{
-			Name: subDomain,
+			Name: name,
 			Type: recordType,
 		},
 		Records: []Record{
 			{
-				Name:    subDomain,
+				Name:    name,
 				Data:    currentIP,
-				TTL:     provider.configuration.Interval,
-				Comment: "Set by GoDNS",
+				TTL:     ttl,
+				Comment: comment,
 			},
 		},
 	}}}}
@@ -103,7 +121,7 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 		return errors.New("failed to encode request body as json")
 	}
 
-	req, _ := http.NewRequest("PATCH", fmt.Sprintf(URL, domain), bytes.NewReader(jsonBody))
+	req, _ := http.NewRequestWithContext(ctx, "PATCH", fmt.Sprintf(provider.API, domain), bytes.NewReader(jsonBody))
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("X-Auth-Token", provider.configuration.LoginToken)
 	if provider.configuration.UserAgent != "" {
@@ -118,6 +136,12 @@ func (provider *DNSProvider) updateIP(domain, subDomain, currentIP string) error
 		return errors.New("failed to complete update request")
 	}
 
+	defer resp.Body.Close()
+
+	if err := utils.CheckRetryAfter(resp); err != nil {
+		return err
+	}
+
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
 		log.Debugf("Update failed for '%s.%s': %s", subDomain, domain, string(body))
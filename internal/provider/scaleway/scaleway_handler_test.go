@@ -0,0 +1,55 @@
+package scaleway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// Wildcard and nested subdomains are set relative to the zone.
+func TestNestedSubdomains(t *testing.T) {
+	tests := []struct {
+		subdomain string
+		ip        string
+		id        IDFields
+	}{
+		{"@", "192.0.2.10", IDFields{Name: "", Type: "A"}},
+		{"*.home", "192.0.2.10", IDFields{Name: "*.home", Type: "A"}},
+		{"vpn.home", "2001:db8::10", IDFields{Name: "vpn.home", Type: "AAAA"}},
+	}
+	for _, tt := range tests {
+		var path string
+		var request DNSUpdateRequest
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPatch {
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+			path = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			_, _ = w.Write([]byte(`{"records":[]}`))
+		}))
+
+		provider := &DNSProvider{
+			API:           srv.URL + "/dns-zones/%s/records",
+			configuration: &settings.Settings{LoginToken: "secret", Interval: 300},
+		}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s failed: %v", tt.subdomain, err)
+		}
+		if path != "/dns-zones/example.com/records" {
+			t.Errorf("%s: unexpected path %s", tt.subdomain, path)
+		}
+		if len(request.Changes) != 1 {
+			t.Fatalf("%s: expected one change, got %+v", tt.subdomain, request.Changes)
+		}
+		set := request.Changes[0].Set
+		if set.IDFields != tt.id || len(set.Records) != 1 || set.Records[0].Name != tt.id.Name || set.Records[0].Data != tt.ip {
+			t.Errorf("%s (%s): unexpected change %+v", tt.subdomain, tt.ip, set)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,71 @@
+package transip
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	"github.com/transip/gotransip/v6/authenticator"
+	"github.com/transip/gotransip/v6/domain"
+)
+
+// Wildcard and nested subdomains are sent relative to the domain, and only
+// match the record of their own name and type, see testdata/nested_records.json.
+func TestNestedSubdomains(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/nested_records.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subdomain string
+		ip        string
+		method    string
+		expire    int
+	}{
+		{"@", "192.0.2.10", http.MethodPatch, 300},
+		{"*.home", "192.0.2.10", http.MethodPatch, 3600},
+		{"vpn.home", "192.0.2.10", http.MethodPatch, 300},
+		// the AAAA record of vpn.home is missing
+		{"vpn.home", "2001:db8::10", http.MethodPost, defaultTTL},
+	}
+	for _, tt := range tests {
+		var method string
+		var request struct {
+			DNSEntry domain.DNSEntry `json:"dnsEntry"`
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/domains/example.com/dns" {
+				t.Errorf("unexpected %s request to %s", r.Method, r.URL.Path)
+			}
+			if r.Method == http.MethodGet {
+				_, _ = w.Write(fixture)
+				return
+			}
+			method = r.Method
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		provider := &DNSProvider{
+			API:           srv.URL,
+			configuration: &settings.Settings{Email: "account", LoginToken: authenticator.DemoToken},
+		}
+		if err := provider.UpdateIP(context.Background(), "example.com", tt.subdomain, tt.ip); err != nil {
+			t.Fatalf("UpdateIP of %s (%s) failed: %v", tt.subdomain, tt.ip, err)
+		}
+		entry := request.DNSEntry
+		if method != tt.method || entry.Name != tt.subdomain || entry.Content != tt.ip || entry.Expire != tt.expire {
+			t.Errorf("%s (%s): expected %s with expire %d, got %s %+v", tt.subdomain, tt.ip, tt.method, tt.expire, method, entry)
+		}
+		srv.Close()
+	}
+}
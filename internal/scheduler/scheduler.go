@@ -0,0 +1,377 @@
+// Package scheduler runs the periodic domain updates from a single loop,
+// bounding how many of them run at the same time.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is a task run periodically by the scheduler.
+type Job struct {
+	// ID identifies the job in the scheduler, Name is only used for display.
+	ID   string
+	Name string
+	// Group is the name of the concurrency limit shared by the job, e.g. the
+	// provider name, matched case-insensitively.
+	Group    string
+	Schedule Schedule
+	Run      func() error
+}
+
+// Scheduler runs the jobs on their schedule, starting with a first run of each
+// job at startup. A job never overlaps with itself: a run which is due while
+// the previous one is still running is skipped.
+type Scheduler struct {
+	mutex         sync.RWMutex
+	entries       []*entry
+	limit         chan struct{}
+	groupLimits   map[string]chan struct{}
+	startupJitter time.Duration
+	trigger       chan struct{}
+	// running is set while Run is running, the entries added meanwhile wait in
+	// pending and the replaced ones in replaced
+	running  bool
+	pending  []*entry
+	replaced []*entry
+	wake     chan struct{}
+}
+
+type entry struct {
+	job     Job
+	next    time.Time
+	running bool
+	// rerun is set when the job is triggered while it is running
+	rerun   bool
+	removed bool
+	// replacement is the job replacing this one once it isn't running
+	replacement *Job
+}
+
+// New creates a scheduler with the given concurrency limits.
+func New(conf settings.Scheduler) *Scheduler {
+	s := &Scheduler{
+		groupLimits:   make(map[string]chan struct{}),
+		startupJitter: time.Duration(conf.StartupJitter) * time.Second,
+		trigger:       make(chan struct{}, 1),
+		wake:          make(chan struct{}, 1),
+	}
+
+	if conf.Concurrency > 0 {
+		s.limit = make(chan struct{}, conf.Concurrency)
+	}
+
+	for group, limit := range conf.ProviderConcurrency {
+		if limit > 0 {
+			s.groupLimits[strings.ToLower(group)] = make(chan struct{}, limit)
+		}
+	}
+
+	return s
+}
+
+// Add adds a job to the scheduler. A job added while the scheduler runs is run right away.
+func (s *Scheduler) Add(job Job) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e := &entry{job: job}
+	s.entries = append(s.entries, e)
+	if s.running {
+		e.next = time.Now()
+		s.pending = append(s.pending, e)
+		s.wakeUp()
+	}
+}
+
+// Replace replaces the job with the same ID, e.g. after a configuration change,
+// or adds it if there is none. The new job runs right away, but never at the
+// same time as the previous one: if it is running, the new job runs once it is finished.
+func (s *Scheduler) Replace(job Job) {
+	s.mutex.Lock()
+	i := slices.IndexFunc(s.entries, func(e *entry) bool { return e.job.ID == job.ID })
+	if i < 0 {
+		s.mutex.Unlock()
+		s.Add(job)
+		return
+	}
+	defer s.mutex.Unlock()
+
+	e := s.entries[i]
+	if !s.running {
+		e.job = job
+		return
+	}
+	e.replacement = &job
+	s.replaced = append(s.replaced, e)
+	s.wakeUp()
+}
+
+// Remove removes the job with the given ID. A running job is not interrupted,
+// but it is not run anymore.
+func (s *Scheduler) Remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = slices.DeleteFunc(s.entries, func(e *entry) bool {
+		if e.job.ID == id {
+			e.removed = true
+			return true
+		}
+		return false
+	})
+	if s.running {
+		s.wakeUp()
+	}
+}
+
+// wakeUp makes the loop of Run apply the added and the removed jobs, the caller must hold the lock.
+func (s *Scheduler) wakeUp() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Trigger runs all the jobs as soon as possible, without changing their schedule.
+func (s *Scheduler) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the jobs until the context is cancelled, then waits for the running ones to return.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	s.mutex.Lock()
+	s.running = true
+	now := time.Now()
+	queue := make(entryQueue, 0, len(s.entries))
+	for i, e := range s.entries {
+		e.next = now.Add(s.startupDelay(i))
+		heap.Push(&queue, e)
+	}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		s.running = false
+		s.pending = nil
+		s.replaced = nil
+		s.mutex.Unlock()
+	}()
+
+	done := make(chan *entry)
+	for {
+		var wait <-chan time.Time
+		var timer *time.Timer
+		if queue.Len() > 0 {
+			timer = time.NewTimer(time.Until(queue[0].next))
+			wait = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			log.Debug("Scheduler cancelled")
+			return
+		case <-wait:
+			now := time.Now()
+			for queue.Len() > 0 && !queue[0].next.After(now) {
+				e := heap.Pop(&queue).(*entry)
+				s.mutex.Lock()
+				e.next = e.job.Schedule.Next(e.next)
+				s.mutex.Unlock()
+				e.running = true
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.run(ctx, e)
+					select {
+					case done <- e:
+					case <-ctx.Done():
+					}
+				}()
+			}
+		case e := <-done:
+			if timer != nil {
+				timer.Stop()
+			}
+			e.running = false
+
+			s.mutex.Lock()
+			if e.removed {
+				s.mutex.Unlock()
+				continue
+			}
+			now := time.Now()
+			if e.replacement != nil {
+				// the job was replaced while it was running
+				e.job, e.replacement = *e.replacement, nil
+				e.rerun = true
+			}
+			if e.rerun {
+				e.next = now
+				e.rerun = false
+			} else if e.next.Before(now) {
+				// skip the runs missed while the job was running
+				e.next = e.job.Schedule.Next(now)
+			}
+			if e.next.IsZero() {
+				// a job without next run would run back-to-back
+				log.Warnf("No next run of %s, schedule: %s", e.job.Name, e.job.Schedule)
+				s.mutex.Unlock()
+				continue
+			}
+			s.mutex.Unlock()
+			heap.Push(&queue, e)
+		case <-s.trigger:
+			if timer != nil {
+				timer.Stop()
+			}
+
+			s.mutex.Lock()
+			now := time.Now()
+			for _, e := range s.entries {
+				if e.running {
+					e.rerun = true
+				} else {
+					e.next = now
+				}
+			}
+			s.mutex.Unlock()
+			heap.Init(&queue)
+		case <-s.wake:
+			if timer != nil {
+				timer.Stop()
+			}
+
+			s.mutex.Lock()
+			queue = slices.DeleteFunc(queue, func(e *entry) bool { return e.removed })
+			for _, e := range s.pending {
+				if !e.removed {
+					queue = append(queue, e)
+				}
+			}
+			s.pending = nil
+
+			// the running jobs are replaced once they are finished
+			now := time.Now()
+			for _, e := range s.replaced {
+				if e.removed || e.running || e.replacement == nil {
+					continue
+				}
+				e.job, e.replacement = *e.replacement, nil
+				e.next = now
+				if !slices.Contains(queue, e) {
+					queue = append(queue, e)
+				}
+			}
+			s.replaced = nil
+			s.mutex.Unlock()
+			heap.Init(&queue)
+		}
+	}
+}
+
+// run runs a job once the group and the global limits allow it.
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	groupLimit := s.groupLimits[strings.ToLower(e.job.Group)]
+	if !acquire(ctx, groupLimit) {
+		return
+	}
+	defer release(groupLimit)
+
+	if !acquire(ctx, s.limit) {
+		return
+	}
+	defer release(s.limit)
+
+	if err := e.job.Run(); err != nil {
+		log.WithError(err).Debugf("Update of %s failed", e.job.Name)
+	}
+	log.Debugf("Update of %s finished, schedule: %s", e.job.Name, e.job.Schedule)
+}
+
+// NextRuns returns the next scheduled run of each job, keyed by job ID.
+// It is empty until the scheduler runs.
+func (s *Scheduler) NextRuns() map[string]time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	nextRuns := make(map[string]time.Time, len(s.entries))
+	for _, e := range s.entries {
+		if !e.next.IsZero() {
+			nextRuns[e.job.ID] = e.next
+		}
+	}
+
+	return nextRuns
+}
+
+// startupDelay spreads the first runs over the startup jitter: each job gets
+// its own slot, and a random offset within it.
+func (s *Scheduler) startupDelay(i int) time.Duration {
+	if s.startupJitter <= 0 {
+		return 0
+	}
+
+	slot := s.startupJitter / time.Duration(len(s.entries))
+	if slot <= 0 {
+		return 0
+	}
+	return time.Duration(i)*slot + time.Duration(rand.Int63n(int64(slot)))
+}
+
+func acquire(ctx context.Context, limit chan struct{}) bool {
+	if limit == nil {
+		return true
+	}
+
+	select {
+	case limit <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func release(limit chan struct{}) {
+	if limit != nil {
+		<-limit
+	}
+}
+
+// entryQueue is a min-heap of entries ordered by their next run.
+type entryQueue []*entry
+
+func (q entryQueue) Len() int           { return len(q) }
+func (q entryQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q entryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *entryQueue) Push(x any) {
+	*q = append(*q, x.(*entry))
+}
+
+func (q *entryQueue) Pop() any {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return e
+}
@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+func TestSchedulerRunsJobsOnInterval(t *testing.T) {
+	s := New(settings.Scheduler{})
+
+	var fast, slow atomic.Int32
+	s.Add(Job{Name: "fast", Schedule: Every(10 * time.Millisecond), Run: func() error {
+		fast.Add(1)
+		return nil
+	}})
+	s.Add(Job{Name: "slow", Schedule: Every(time.Hour), Run: func() error {
+		slow.Add(1)
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if fast.Load() < 3 {
+		t.Errorf("expected the fast job to run several times, got %d", fast.Load())
+	}
+	if slow.Load() != 1 {
+		t.Errorf("expected the slow job to run once, got %d", slow.Load())
+	}
+}
+
+func TestSchedulerConcurrencyLimits(t *testing.T) {
+	s := New(settings.Scheduler{Concurrency: 3, ProviderConcurrency: map[string]int{"cloudflare": 1}})
+
+	var running, maxRunning, cloudflareRunning, maxCloudflareRunning atomic.Int32
+	track := func(counter, maxCounter *atomic.Int32) {
+		n := counter.Add(1)
+		for {
+			m := maxCounter.Load()
+			if n <= m || maxCounter.CompareAndSwap(m, n) {
+				break
+			}
+		}
+	}
+
+	// the groups are matched case-insensitively, e.g. the Cloudflare provider
+	for _, group := range []string{"Cloudflare", "cloudflare", "Cloudflare", "dnspod", "dnspod", "dnspod"} {
+		s.Add(Job{Name: group, Group: group, Schedule: Every(time.Hour), Run: func() error {
+			track(&running, &maxRunning)
+			if strings.EqualFold(group, "cloudflare") {
+				track(&cloudflareRunning, &maxCloudflareRunning)
+				defer cloudflareRunning.Add(-1)
+			}
+			defer running.Add(-1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if maxRunning.Load() > 3 {
+		t.Errorf("expected at most 3 concurrent jobs, got %d", maxRunning.Load())
+	}
+	if maxCloudflareRunning.Load() != 1 {
+		t.Errorf("expected at most 1 concurrent cloudflare job, got %d", maxCloudflareRunning.Load())
+	}
+}
+
+func TestSchedulerStartupDelay(t *testing.T) {
+	s := New(settings.Scheduler{StartupJitter: 40})
+	for i := 0; i < 4; i++ {
+		s.Add(Job{Schedule: Every(time.Minute), Run: func() error { return nil }})
+	}
+
+	for i := 0; i < 4; i++ {
+		delay := s.startupDelay(i)
+		if delay < time.Duration(i)*10*time.Second || delay >= time.Duration(i+1)*10*time.Second {
+			t.Errorf("job %d: startup delay %s out of its slot", i, delay)
+		}
+	}
+}
+
+func TestSchedulerReturnsOnCancel(t *testing.T) {
+	s := New(settings.Scheduler{StartupJitter: 3600})
+	s.Add(Job{Schedule: Every(time.Minute), Run: func() error { return nil }})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return within 2s of ctx cancellation")
+	}
+}
+
+func TestSchedulerNextRuns(t *testing.T) {
+	s := New(settings.Scheduler{})
+	s.Add(Job{ID: "cloudflare/example.com", Name: "example.com", Schedule: Every(time.Hour), Run: func() error { return nil }})
+	s.Add(Job{ID: "dnspod/example.com", Name: "example.com", Schedule: Every(time.Minute), Run: func() error { return nil }})
+
+	if len(s.NextRuns()) != 0 {
+		t.Fatal("expected no next run before the scheduler runs")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	s.Run(ctx)
+
+	next, ok := s.NextRuns()["cloudflare/example.com"]
+	if !ok {
+		t.Fatal("expected a next run for cloudflare/example.com")
+	}
+	if next.Before(start.Add(time.Hour)) || next.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expected the next run an hour after the first one, got %s", next)
+	}
+
+	// the domains of the same name are told apart by their provider
+	if next, ok := s.NextRuns()["dnspod/example.com"]; !ok || next.After(time.Now().Add(time.Minute)) {
+		t.Errorf("expected a next run a minute after the first one for dnspod/example.com, got %v", s.NextRuns())
+	}
+}
+
+// never is a schedule without next run.
+type never struct{}
+
+func (never) Next(time.Time) time.Time { return time.Time{} }
+
+func TestSchedulerWithoutNextRun(t *testing.T) {
+	s := New(settings.Scheduler{})
+
+	var runs atomic.Int32
+	s.Add(Job{Name: "example.com", Schedule: never{}, Run: func() error {
+		runs.Add(1)
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	if runs.Load() != 1 {
+		t.Errorf("expected the job to only run at startup, got %d runs", runs.Load())
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	s := New(settings.Scheduler{})
+
+	var runs atomic.Int32
+	s.Add(Job{Name: "example.com", Schedule: Every(time.Hour), Run: func() error {
+		runs.Add(1)
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.Trigger()
+	}()
+	s.Run(ctx)
+
+	if runs.Load() != 2 {
+		t.Errorf("expected the job to run at startup and when triggered, got %d runs", runs.Load())
+	}
+}
+
+func TestSchedulerAddAndRemoveWhileRunning(t *testing.T) {
+	s := New(settings.Scheduler{})
+
+	var removed, added atomic.Int32
+	s.Add(Job{ID: "removed", Schedule: Every(10 * time.Millisecond), Run: func() error {
+		removed.Add(1)
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.Remove("removed")
+		s.Add(Job{ID: "added", Name: "example.com", Schedule: Every(time.Hour), Run: func() error {
+			added.Add(1)
+			return nil
+		}})
+	}()
+	s.Run(ctx)
+
+	if n := removed.Load(); n == 0 || n > 7 {
+		t.Errorf("expected the removed job to stop running after its removal, got %d runs", n)
+	}
+	if added.Load() != 1 {
+		t.Errorf("expected the added job to run right away, got %d runs", added.Load())
+	}
+	if _, exists := s.NextRuns()["added"]; !exists || len(s.NextRuns()) != 1 {
+		t.Errorf("unexpected next runs: %v", s.NextRuns())
+	}
+}
+
+func TestSchedulerReplaceWhileRunning(t *testing.T) {
+	s := New(settings.Scheduler{})
+
+	release := make(chan struct{})
+	var previousRunning, overlaps, replaced atomic.Int32
+	s.Add(Job{ID: "example.com", Schedule: Every(time.Hour), Run: func() error {
+		previousRunning.Store(1)
+		<-release
+		previousRunning.Store(0)
+		return nil
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		s.Replace(Job{ID: "example.com", Schedule: Every(time.Hour), Run: func() error {
+			if previousRunning.Load() == 1 {
+				overlaps.Add(1)
+			}
+			replaced.Add(1)
+			return nil
+		}})
+		time.Sleep(30 * time.Millisecond)
+		close(release)
+	}()
+	s.Run(ctx)
+
+	if overlaps.Load() != 0 {
+		t.Error("expected the new job not to run while the previous one is running")
+	}
+	if replaced.Load() != 1 {
+		t.Errorf("expected the new job to run once the previous one finished, got %d runs", replaced.Load())
+	}
+	if len(s.NextRuns()) != 1 {
+		t.Errorf("unexpected next runs: %v", s.NextRuns())
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/TimothyYe/godns/internal/settings"
@@ -16,62 +17,90 @@ type BasicInfo struct {
 	SubDomainNum    int               `json:"sub_domain_num"`
 	Domains         []settings.Domain `json:"domains"`
 	PublicIP        string            `json:"public_ip"`
+	PublicIPV6      string            `json:"public_ipv6,omitempty"`
 	IPMode          string            `json:"ip_mode"`
 	Provider        string            `json:"provider"`
 	IsMultiProvider bool              `json:"is_multi_provider"`
 	Providers       []string          `json:"providers"`
+	// NextRuns holds the next scheduled update of each domain as a unix timestamp,
+	// keyed by provider and domain name, e.g. "Cloudflare/example.com".
+	NextRuns map[string]int64 `json:"next_runs,omitempty"`
 }
 
 func (c *Controller) GetBasicInfo(ctx *fiber.Ctx) error {
-	isMultiProvider := c.config.IsMultiProvider()
-	providers := c.getProviders()
+	config := c.getConfig()
+	isMultiProvider := config.IsMultiProvider()
+	providers := getProviders(config)
+
+	// public_ip holds the IPv4 address if any domain uses it, public_ipv6
+	// the IPv6 address if any domain uses it, whatever the global ip_type
+	ipHelper := lib.GetIPHelperInstance(config)
+	var publicIPV6 string
+	if slices.Contains(utils.GetConfiguredIPTypes(config), utils.IPV6) {
+		publicIPV6 = ipHelper.GetCurrentIPByType(utils.IPV6)
+	}
 
 	return ctx.JSON(BasicInfo{
 		Version:         utils.Version,
 		StartTime:       utils.StartTime,
-		DomainNum:       c.getDomains(),
-		SubDomainNum:    c.GetSubDomains(),
-		Domains:         c.config.Domains,
-		PublicIP:        lib.GetIPHelperInstance(c.config).GetCurrentIP(),
-		IPMode:          strings.ToUpper(c.config.IPType),
-		Provider:        c.config.Provider,
+		DomainNum:       len(config.Domains),
+		SubDomainNum:    countSubDomains(config),
+		Domains:         settings.DisplayDomains(config.Domains),
+		PublicIP:        ipHelper.GetCurrentIP(),
+		PublicIPV6:      publicIPV6,
+		IPMode:          strings.ToUpper(config.IPType),
+		Provider:        config.Provider,
 		IsMultiProvider: isMultiProvider,
 		Providers:       providers,
+		NextRuns:        c.getNextRuns(),
 	})
 }
 
-func (c *Controller) getDomains() int {
-	// count the total number of domains
-	return len(c.config.Domains)
+func (c *Controller) getNextRuns() map[string]int64 {
+	updateScheduler := c.scheduler.Load()
+	if updateScheduler == nil {
+		return nil
+	}
+
+	nextRuns := make(map[string]int64)
+	for domain, next := range updateScheduler.NextRuns() {
+		nextRuns[domain] = next.Unix()
+	}
+
+	return nextRuns
 }
 
 func (c *Controller) GetSubDomains() int {
+	return countSubDomains(c.getConfig())
+}
+
+func countSubDomains(config *settings.Settings) int {
 	// get the total number of all the sub domains
 	var count int
-	for _, domain := range c.config.Domains {
+	for _, domain := range config.Domains {
 		count += len(domain.SubDomains)
 	}
 
 	return count
 }
 
-func (c *Controller) getProviders() []string {
+func getProviders(config *settings.Settings) []string {
 	providersSet := make(map[string]bool)
 
 	// Add global provider if specified (legacy single provider mode)
-	if c.config.Provider != "" {
-		providersSet[c.config.Provider] = true
+	if config.Provider != "" {
+		providersSet[config.Provider] = true
 	}
 
 	// Add providers from multi-provider configuration
-	if c.config.Providers != nil {
-		for providerName := range c.config.Providers {
+	if config.Providers != nil {
+		for providerName := range config.Providers {
 			providersSet[providerName] = true
 		}
 	}
 
 	// Add providers from domains (for mixed configuration)
-	for _, domain := range c.config.Domains {
+	for _, domain := range config.Domains {
 		if domain.Provider != "" {
 			providersSet[domain.Provider] = true
 		}
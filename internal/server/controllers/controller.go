@@ -1,20 +1,52 @@
 package controllers
 
 import (
+	"sync/atomic"
+
+	"github.com/TimothyYe/godns/internal/scheduler"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
 )
 
+// Controller serves the web panel API. The configuration and the scheduler are
+// replaced on reload while the requests are served, so they are held atomically.
 type Controller struct {
-	config     *settings.Settings
+	config     atomic.Pointer[settings.Settings]
 	configPath string
+	scheduler  atomic.Pointer[scheduler.Scheduler]
 }
 
 func NewController(conf *settings.Settings, configPath string) *Controller {
-	return &Controller{
-		config:     conf,
+	c := &Controller{
 		configPath: configPath,
 	}
+	c.config.Store(conf)
+	return c
+}
+
+func (c *Controller) SetConfig(conf *settings.Settings) {
+	c.config.Store(conf)
+}
+
+func (c *Controller) SetScheduler(scheduler *scheduler.Scheduler) {
+	c.scheduler.Store(scheduler)
+}
+
+// getConfig returns the running configuration, which must not be modified:
+// the changes are made on a copy, saved to the configuration file and applied
+// once the file is reloaded.
+func (c *Controller) getConfig() *settings.Settings {
+	return c.config.Load()
+}
+
+// saveConfig writes the edited copy of the configuration to the configuration file.
+func (c *Controller) saveConfig(config *settings.Settings) error {
+	if err := config.SaveSettings(c.configPath); err != nil {
+		log.Errorf("Failed to save settings: %s", err.Error())
+		return err
+	}
+	return nil
 }
 
 func (c *Controller) Auth(ctx *fiber.Ctx) error {
@@ -1,13 +1,15 @@
 package controllers
 
 import (
+	"slices"
+
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
 )
 
 func (c *Controller) GetDomains(ctx *fiber.Ctx) error {
-	return ctx.JSON(c.config.Domains)
+	return ctx.JSON(settings.DisplayDomains(c.getConfig().ConfiguredDomains()))
 }
 
 func (c *Controller) AddDomain(ctx *fiber.Ctx) error {
@@ -16,14 +18,11 @@ func (c *Controller) AddDomain(ctx *fiber.Ctx) error {
 		log.Errorf("Failed to parse request body: %s", err.Error())
 		return ctx.Status(400).SendString(err.Error())
 	}
-
-	c.config.Domains = append(c.config.Domains, domain)
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
-		return ctx.Status(500).SendString("Failed to save settings")
+	if err := settings.NormalizeDomain(&domain); err != nil {
+		return ctx.Status(400).SendString(err.Error())
 	}
 
-	return ctx.JSON(c.config.Domains)
+	return c.saveDomains(ctx, append(slices.Clone(c.getConfig().ConfiguredDomains()), domain))
 }
 
 func (c *Controller) DeleteDomain(ctx *fiber.Ctx) error {
@@ -33,17 +32,24 @@ func (c *Controller) DeleteDomain(ctx *fiber.Ctx) error {
 	}
 
 	var domains []settings.Domain
-	for _, domain := range c.config.Domains {
-		if domain.DomainName != domainName {
+	for _, domain := range c.getConfig().ConfiguredDomains() {
+		if !settings.SameDomain(domain.DomainName, domainName) {
 			domains = append(domains, domain)
 		}
 	}
 
-	c.config.Domains = domains
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
+	return c.saveDomains(ctx, domains)
+}
+
+// saveDomains writes the configuration with the given domains. The running
+// configuration is left untouched: it is replaced once the configuration file
+// is reloaded, which compares it with the saved one to apply the changes.
+func (c *Controller) saveDomains(ctx *fiber.Ctx, domains []settings.Domain) error {
+	config := c.getConfig().Clone()
+	config.SetConfiguredDomains(domains)
+	if err := c.saveConfig(config); err != nil {
 		return ctx.Status(500).SendString("Failed to save settings")
 	}
 
-	return ctx.JSON(c.config.Domains)
+	return ctx.JSON(settings.DisplayDomains(config.ConfiguredDomains()))
 }
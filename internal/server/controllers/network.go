@@ -20,16 +20,17 @@ type NetworkSettings struct {
 }
 
 func (c *Controller) GetNetworkSettings(ctx *fiber.Ctx) error {
+	config := c.getConfig()
 	settings := NetworkSettings{
-		IPMode:        c.config.IPType,
-		IPUrls:        c.config.IPUrls,
-		IPV6Urls:      c.config.IPV6Urls,
-		UseProxy:      c.config.UseProxy,
-		SkipSSLVerify: c.config.SkipSSLVerify,
-		Socks5Proxy:   c.config.Socks5Proxy,
-		Webhook:       c.config.Webhook,
-		Resolver:      c.config.Resolver,
-		IPInterface:   c.config.IPInterface,
+		IPMode:        config.IPType,
+		IPUrls:        config.IPUrls,
+		IPV6Urls:      config.IPV6Urls,
+		UseProxy:      config.UseProxy,
+		SkipSSLVerify: config.SkipSSLVerify,
+		Socks5Proxy:   config.Socks5Proxy,
+		Webhook:       config.Webhook,
+		Resolver:      config.Resolver,
+		IPInterface:   config.IPInterface,
 	}
 
 	return ctx.JSON(settings)
@@ -42,30 +43,35 @@ func (c *Controller) UpdateNetworkSettings(ctx *fiber.Ctx) error {
 		return ctx.Status(400).SendString(err.Error())
 	}
 
-	if settings.IPMode == utils.IPV4 && len(settings.IPUrls) == 0 {
-		return ctx.Status(400).SendString("IP URLs cannot be empty")
-	}
+	ipTypes := utils.GetIPTypes(settings.IPMode)
+	for _, ipType := range ipTypes {
+		if ipType == utils.IPV4 && len(settings.IPUrls) == 0 {
+			return ctx.Status(400).SendString("IP URLs cannot be empty")
+		}
 
-	if settings.IPMode == utils.IPV6 && len(settings.IPV6Urls) == 0 {
-		return ctx.Status(400).SendString("IPv6 URLs cannot be empty")
+		if ipType == utils.IPV6 && len(settings.IPV6Urls) == 0 {
+			return ctx.Status(400).SendString("IPv6 URLs cannot be empty")
+		}
 	}
 
-	c.config.IPType = settings.IPMode
-	if settings.IPMode == utils.IPV6 {
-		c.config.IPV6Urls = settings.IPV6Urls
-	} else {
-		c.config.IPUrls = settings.IPUrls
+	config := c.getConfig().Clone()
+	config.IPType = settings.IPMode
+	for _, ipType := range ipTypes {
+		if ipType == utils.IPV6 {
+			config.IPV6Urls = settings.IPV6Urls
+		} else {
+			config.IPUrls = settings.IPUrls
+		}
 	}
 
-	c.config.UseProxy = settings.UseProxy
-	c.config.SkipSSLVerify = settings.SkipSSLVerify
-	c.config.Socks5Proxy = settings.Socks5Proxy
-	c.config.Webhook = settings.Webhook
-	c.config.Resolver = settings.Resolver
-	c.config.IPInterface = settings.IPInterface
+	config.UseProxy = settings.UseProxy
+	config.SkipSSLVerify = settings.SkipSSLVerify
+	config.Socks5Proxy = settings.Socks5Proxy
+	config.Webhook = settings.Webhook
+	config.Resolver = settings.Resolver
+	config.IPInterface = settings.IPInterface
 
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
+	if err := c.saveConfig(config); err != nil {
 		return ctx.Status(500).SendString("Failed to save network settings")
 	}
 
@@ -1,10 +1,9 @@
 package controllers
 
 import (
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
-	"github.com/TimothyYe/godns/internal/utils"
 	"github.com/gofiber/fiber/v2"
-	log "github.com/sirupsen/logrus"
 )
 
 type Provider struct {
@@ -18,20 +17,21 @@ type Provider struct {
 }
 
 func (c *Controller) GetProvider(ctx *fiber.Ctx) error {
+	config := c.getConfig()
 	provider := Provider{
-		Provider:    c.config.Provider,
-		Email:       c.config.Email,
-		Password:    c.config.Password,
-		LoginToken:  c.config.LoginToken,
-		AppKey:      c.config.AppKey,
-		AppSecret:   c.config.AppSecret,
-		ConsumerKey: c.config.ConsumerKey,
+		Provider:    config.Provider,
+		Email:       config.Email,
+		Password:    config.Password,
+		LoginToken:  config.LoginToken,
+		AppKey:      config.AppKey,
+		AppSecret:   config.AppSecret,
+		ConsumerKey: config.ConsumerKey,
 	}
 	return ctx.JSON(provider)
 }
 
 func (c *Controller) GetProviderSettings(ctx *fiber.Ctx) error {
-	return ctx.JSON(utils.Providers)
+	return ctx.JSON(registry.Providers())
 }
 
 func (c *Controller) UpdateProvider(ctx *fiber.Ctx) error {
@@ -40,16 +40,16 @@ func (c *Controller) UpdateProvider(ctx *fiber.Ctx) error {
 		return err
 	}
 
-	c.config.Provider = provider.Provider
-	c.config.Email = provider.Email
-	c.config.Password = provider.Password
-	c.config.LoginToken = provider.LoginToken
-	c.config.AppKey = provider.AppKey
-	c.config.AppSecret = provider.AppSecret
-	c.config.ConsumerKey = provider.ConsumerKey
+	config := c.getConfig().Clone()
+	config.Provider = provider.Provider
+	config.Email = provider.Email
+	config.Password = provider.Password
+	config.LoginToken = provider.LoginToken
+	config.AppKey = provider.AppKey
+	config.AppSecret = provider.AppSecret
+	config.ConsumerKey = provider.ConsumerKey
 
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
+	if err := c.saveConfig(config); err != nil {
 		return ctx.Status(500).SendString("Failed to save settings")
 	}
 
@@ -58,27 +58,28 @@ func (c *Controller) UpdateProvider(ctx *fiber.Ctx) error {
 
 func (c *Controller) GetMultiProviders(ctx *fiber.Ctx) error {
 	// Combine legacy provider config with new multi-provider config
+	config := c.getConfig()
 	result := make(map[string]*settings.ProviderConfig)
 
 	// First, add all providers from the new multi-provider map
-	if c.config.Providers != nil {
-		for name, config := range c.config.Providers {
-			result[name] = config
+	if config.Providers != nil {
+		for name, providerConfig := range config.Providers {
+			result[name] = providerConfig
 		}
 	}
 
 	// Then, add the legacy provider if it exists and isn't already in the map
-	if c.config.Provider != "" {
-		if _, exists := result[c.config.Provider]; !exists {
-			result[c.config.Provider] = &settings.ProviderConfig{
-				Email:          c.config.Email,
-				Password:       c.config.Password,
-				PasswordFile:   c.config.PasswordFile,
-				LoginToken:     c.config.LoginToken,
-				LoginTokenFile: c.config.LoginTokenFile,
-				AppKey:         c.config.AppKey,
-				AppSecret:      c.config.AppSecret,
-				ConsumerKey:    c.config.ConsumerKey,
+	if config.Provider != "" {
+		if _, exists := result[config.Provider]; !exists {
+			result[config.Provider] = &settings.ProviderConfig{
+				Email:          config.Email,
+				Password:       config.Password,
+				PasswordFile:   config.PasswordFile,
+				LoginToken:     config.LoginToken,
+				LoginTokenFile: config.LoginTokenFile,
+				AppKey:         config.AppKey,
+				AppSecret:      config.AppSecret,
+				ConsumerKey:    config.ConsumerKey,
 			}
 		}
 	}
@@ -92,37 +93,38 @@ func (c *Controller) UpdateMultiProviders(ctx *fiber.Ctx) error {
 		return err
 	}
 
+	config := c.getConfig().Clone()
+
 	// If there's a legacy provider configured, check if it's in the input
-	if c.config.Provider != "" {
-		if legacyConfig, hasLegacy := providers[c.config.Provider]; hasLegacy {
+	if config.Provider != "" {
+		if legacyConfig, hasLegacy := providers[config.Provider]; hasLegacy {
 			// Update the legacy top-level fields
-			c.config.Email = legacyConfig.Email
-			c.config.Password = legacyConfig.Password
-			c.config.PasswordFile = legacyConfig.PasswordFile
-			c.config.LoginToken = legacyConfig.LoginToken
-			c.config.LoginTokenFile = legacyConfig.LoginTokenFile
-			c.config.AppKey = legacyConfig.AppKey
-			c.config.AppSecret = legacyConfig.AppSecret
-			c.config.ConsumerKey = legacyConfig.ConsumerKey
+			config.Email = legacyConfig.Email
+			config.Password = legacyConfig.Password
+			config.PasswordFile = legacyConfig.PasswordFile
+			config.LoginToken = legacyConfig.LoginToken
+			config.LoginTokenFile = legacyConfig.LoginTokenFile
+			config.AppKey = legacyConfig.AppKey
+			config.AppSecret = legacyConfig.AppSecret
+			config.ConsumerKey = legacyConfig.ConsumerKey
 
 			// Create a new map with only the non-legacy providers
 			nonLegacyProviders := make(map[string]*settings.ProviderConfig)
-			for name, config := range providers {
-				if name != c.config.Provider {
-					nonLegacyProviders[name] = config
+			for name, providerConfig := range providers {
+				if name != config.Provider {
+					nonLegacyProviders[name] = providerConfig
 				}
 			}
 
 			// Update the providers map with only non-legacy providers
 			if len(nonLegacyProviders) > 0 {
-				c.config.Providers = nonLegacyProviders
+				config.Providers = nonLegacyProviders
 			} else {
 				// If no other providers, clear the providers map
-				c.config.Providers = nil
+				config.Providers = nil
 			}
 
-			if err := c.config.SaveSettings(c.configPath); err != nil {
-				log.Errorf("Failed to save settings: %s", err.Error())
+			if err := c.saveConfig(config); err != nil {
 				return ctx.Status(500).SendString("Failed to save settings")
 			}
 
@@ -131,10 +133,9 @@ func (c *Controller) UpdateMultiProviders(ctx *fiber.Ctx) error {
 	}
 
 	// No legacy provider match, update the entire providers map
-	c.config.Providers = providers
+	config.Providers = providers
 
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
+	if err := c.saveConfig(config); err != nil {
 		return ctx.Status(500).SendString("Failed to save settings")
 	}
 
@@ -147,19 +148,19 @@ func (c *Controller) AddProviderConfig(ctx *fiber.Ctx) error {
 		return ctx.Status(400).SendString("Provider name is required")
 	}
 
-	var config settings.ProviderConfig
-	if err := ctx.BodyParser(&config); err != nil {
+	var providerConfig settings.ProviderConfig
+	if err := ctx.BodyParser(&providerConfig); err != nil {
 		return err
 	}
 
-	if c.config.Providers == nil {
-		c.config.Providers = make(map[string]*settings.ProviderConfig)
+	config := c.getConfig().Clone()
+	if config.Providers == nil {
+		config.Providers = make(map[string]*settings.ProviderConfig)
 	}
 
-	c.config.Providers[providerName] = &config
+	config.Providers[providerName] = &providerConfig
 
-	if err := c.config.SaveSettings(c.configPath); err != nil {
-		log.Errorf("Failed to save settings: %s", err.Error())
+	if err := c.saveConfig(config); err != nil {
 		return ctx.Status(500).SendString("Failed to save settings")
 	}
 
@@ -172,11 +173,11 @@ func (c *Controller) DeleteProviderConfig(ctx *fiber.Ctx) error {
 		return ctx.Status(400).SendString("Provider name is required")
 	}
 
-	if c.config.Providers != nil {
-		delete(c.config.Providers, providerName)
+	config := c.getConfig().Clone()
+	if config.Providers != nil {
+		delete(config.Providers, providerName)
 
-		if err := c.config.SaveSettings(c.configPath); err != nil {
-			log.Errorf("Failed to save settings: %s", err.Error())
+		if err := c.saveConfig(config); err != nil {
 			return ctx.Status(500).SendString("Failed to save settings")
 		}
 	}
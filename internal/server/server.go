@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/TimothyYe/godns/internal/scheduler"
 	"github.com/TimothyYe/godns/internal/server/controllers"
 	"github.com/TimothyYe/godns/internal/settings"
 	"github.com/gofiber/fiber/v2"
@@ -27,6 +28,7 @@ type Server struct {
 	controller *controllers.Controller
 	config     *settings.Settings
 	configPath string
+	scheduler  *scheduler.Scheduler
 }
 
 func (s *Server) SetAddress(addr string) *Server {
@@ -42,6 +44,9 @@ func (s *Server) SetAuthInfo(username, password string) *Server {
 
 func (s *Server) SetConfig(config *settings.Settings) *Server {
 	s.config = config
+	if s.controller != nil {
+		s.controller.SetConfig(config)
+	}
 	return s
 }
 
@@ -50,10 +55,19 @@ func (s *Server) SetConfigPath(configPath string) *Server {
 	return s
 }
 
+func (s *Server) SetScheduler(scheduler *scheduler.Scheduler) *Server {
+	s.scheduler = scheduler
+	if s.controller != nil {
+		s.controller.SetScheduler(scheduler)
+	}
+	return s
+}
+
 func (s *Server) Build() {
 	config := fiber.Config{}
 	s.app = fiber.New(config)
 	s.controller = controllers.NewController(s.config, s.configPath)
+	s.controller.SetScheduler(s.scheduler)
 }
 
 func (s *Server) Start() error {
@@ -0,0 +1,87 @@
+package settings
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Diff holds the changes between two configurations.
+type Diff struct {
+	// Global is true when a setting shared by all the domains changed, e.g. the IP sources.
+	Global   bool
+	WebPanel bool
+	// Providers lists the names of the added, removed or changed providers of the providers section.
+	Providers []string
+	// AddedDomains, RemovedDomains and ChangedDomains hold domain keys, see DomainKey.
+	AddedDomains   []string
+	RemovedDomains []string
+	ChangedDomains []string
+}
+
+// IsEmpty returns true if both configurations are the same.
+func (d *Diff) IsEmpty() bool {
+	return !d.Global && !d.WebPanel && len(d.Providers) == 0 &&
+		len(d.AddedDomains) == 0 && len(d.RemovedDomains) == 0 && len(d.ChangedDomains) == 0
+}
+
+// DomainKey returns the key identifying a domain across configurations, e.g. "cloudflare/example.com".
+func (s *Settings) DomainKey(domain *Domain) string {
+	return s.GetDomainProvider(domain) + "/" + domain.DomainName
+}
+
+// Compare returns the changes from the old configuration to the new one.
+func Compare(oldConf, newConf *Settings) *Diff {
+	diff := &Diff{
+		WebPanel: !reflect.DeepEqual(oldConf.WebPanel, newConf.WebPanel),
+	}
+
+	// everything but the domains, the providers section and the web panel is global
+	oldGlobal, newGlobal := *oldConf, *newConf
+	oldGlobal.Domains, newGlobal.Domains = nil, nil
+	oldGlobal.configuredDomains, newGlobal.configuredDomains = nil, nil
+	oldGlobal.Providers, newGlobal.Providers = nil, nil
+	oldGlobal.WebPanel, newGlobal.WebPanel = WebPanel{}, WebPanel{}
+	diff.Global = !reflect.DeepEqual(oldGlobal, newGlobal) || oldConf.IsMultiProvider() != newConf.IsMultiProvider()
+
+	for name, oldProvider := range oldConf.Providers {
+		if newProvider, exists := newConf.Providers[name]; !exists || !reflect.DeepEqual(oldProvider, newProvider) {
+			diff.Providers = append(diff.Providers, name)
+		}
+	}
+	for name := range newConf.Providers {
+		if _, exists := oldConf.Providers[name]; !exists {
+			diff.Providers = append(diff.Providers, name)
+		}
+	}
+	slices.Sort(diff.Providers)
+
+	oldDomains := oldConf.domainsByKey()
+	newDomains := newConf.domainsByKey()
+	for key, oldDomain := range oldDomains {
+		newDomain, exists := newDomains[key]
+		switch {
+		case !exists:
+			diff.RemovedDomains = append(diff.RemovedDomains, key)
+		case !reflect.DeepEqual(oldDomain, newDomain):
+			diff.ChangedDomains = append(diff.ChangedDomains, key)
+		}
+	}
+	for key := range newDomains {
+		if _, exists := oldDomains[key]; !exists {
+			diff.AddedDomains = append(diff.AddedDomains, key)
+		}
+	}
+	slices.Sort(diff.AddedDomains)
+	slices.Sort(diff.RemovedDomains)
+	slices.Sort(diff.ChangedDomains)
+
+	return diff
+}
+
+func (s *Settings) domainsByKey() map[string]*Domain {
+	domains := make(map[string]*Domain, len(s.Domains))
+	for i := range s.Domains {
+		domains[s.DomainKey(&s.Domains[i])] = &s.Domains[i]
+	}
+	return domains
+}
@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"slices"
+	"testing"
+)
+
+func newDiffTestSettings() *Settings {
+	return &Settings{
+		Interval: 300,
+		Providers: map[string]*ProviderConfig{
+			"cloudflare": {LoginToken: "token"},
+			"dnspod":     {LoginToken: "token"},
+		},
+		Domains: []Domain{
+			{DomainName: "example.com", SubDomains: []string{"www"}, Provider: "cloudflare"},
+			{DomainName: "example.org", SubDomains: []string{"www"}, Provider: "dnspod"},
+			{DomainName: "example.net", SubDomains: []string{"www"}, Provider: "dnspod"},
+		},
+	}
+}
+
+func TestCompareSameSettings(t *testing.T) {
+	if diff := Compare(newDiffTestSettings(), newDiffTestSettings()); !diff.IsEmpty() {
+		t.Errorf("expected no change, got %+v", diff)
+	}
+}
+
+func TestCompareDomains(t *testing.T) {
+	oldConf, newConf := newDiffTestSettings(), newDiffTestSettings()
+	newConf.Domains[0].SubDomains = append(newConf.Domains[0].SubDomains, "api")
+	// moving a domain to another provider removes it from the old one
+	newConf.Domains[1].Provider = "cloudflare"
+	newConf.Domains = append(newConf.Domains[:2], Domain{DomainName: "example.io", Provider: "dnspod"})
+
+	diff := Compare(oldConf, newConf)
+	if diff.Global || diff.WebPanel || len(diff.Providers) != 0 {
+		t.Errorf("expected only domain changes, got %+v", diff)
+	}
+	if !slices.Equal(diff.ChangedDomains, []string{"cloudflare/example.com"}) {
+		t.Errorf("unexpected changed domains: %v", diff.ChangedDomains)
+	}
+	if !slices.Equal(diff.AddedDomains, []string{"cloudflare/example.org", "dnspod/example.io"}) {
+		t.Errorf("unexpected added domains: %v", diff.AddedDomains)
+	}
+	if !slices.Equal(diff.RemovedDomains, []string{"dnspod/example.net", "dnspod/example.org"}) {
+		t.Errorf("unexpected removed domains: %v", diff.RemovedDomains)
+	}
+}
+
+func TestCompareProvidersAndGlobalSettings(t *testing.T) {
+	oldConf, newConf := newDiffTestSettings(), newDiffTestSettings()
+	newConf.Providers["dnspod"] = &ProviderConfig{LoginToken: "new-token"}
+	newConf.Providers["hetzner"] = &ProviderConfig{LoginToken: "token"}
+	newConf.WebPanel.Enabled = true
+
+	diff := Compare(oldConf, newConf)
+	if diff.Global {
+		t.Error("expected no global change")
+	}
+	if !diff.WebPanel {
+		t.Error("expected a web panel change")
+	}
+	if !slices.Equal(diff.Providers, []string{"dnspod", "hetzner"}) {
+		t.Errorf("unexpected changed providers: %v", diff.Providers)
+	}
+
+	newConf.Interval = 60
+	if diff := Compare(oldConf, newConf); !diff.Global {
+		t.Error("expected a global change")
+	}
+}
@@ -0,0 +1,143 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ToASCII converts a domain or subdomain name to its A-labels, e.g. bücher.example
+// to xn--bcher-kva.example, so that the providers and the DNS lookups always get
+// the same name. The name is lowercased and its trailing dot removed. The ASCII
+// labels are only lowercased, as they may be @, a wildcard or start with an underscore.
+func ToASCII(name string) (string, error) {
+	name = strings.TrimSuffix(name, ".")
+
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if isASCII(label) {
+			labels[i] = strings.ToLower(label)
+			// the A-labels must be valid punycode
+			if strings.HasPrefix(labels[i], "xn--") {
+				if _, err := idna.Lookup.ToUnicode(labels[i]); err != nil {
+					return "", fmt.Errorf("invalid name '%s': %w", name, err)
+				}
+			}
+			continue
+		}
+
+		ascii, err := idna.Lookup.ToASCII(label)
+		if err != nil {
+			return "", fmt.Errorf("invalid name '%s': %w", name, err)
+		}
+		labels[i] = ascii
+	}
+
+	return strings.Join(labels, "."), nil
+}
+
+// ToUnicode converts a domain or subdomain name to its U-labels for display, e.g.
+// xn--bcher-kva.example to bücher.example. The labels which can't be converted are kept.
+func ToUnicode(name string) string {
+	if !strings.Contains(name, "xn--") {
+		return name
+	}
+
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if unicode, err := idna.Display.ToUnicode(label); err == nil {
+			labels[i] = unicode
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// SameDomain returns true if both names are the same domain, e.g. bücher.example.
+// and xn--bcher-kva.example.
+func SameDomain(a, b string) bool {
+	asciiA, errA := ToASCII(a)
+	asciiB, errB := ToASCII(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	}
+	return asciiA == asciiB
+}
+
+// NormalizeDomain converts the names of a domain, its subdomains and hostnames to A-labels.
+func NormalizeDomain(domain *Domain) error {
+	var err error
+	if domain.DomainName != "" {
+		if domain.DomainName, err = ToASCII(domain.DomainName); err != nil {
+			return err
+		}
+	}
+
+	for i, subDomain := range domain.SubDomains {
+		if domain.SubDomains[i], err = ToASCII(subDomain); err != nil {
+			return err
+		}
+	}
+
+	for i, hostname := range domain.Hostnames {
+		if domain.Hostnames[i], err = ToASCII(hostname); err != nil {
+			return err
+		}
+	}
+
+	if len(domain.SubDomainOptions) > 0 {
+		options := make(map[string]SubDomainOptions, len(domain.SubDomainOptions))
+		for name, option := range domain.SubDomainOptions {
+			ascii, err := ToASCII(name)
+			if err != nil {
+				return err
+			}
+			if _, exists := options[ascii]; exists {
+				return fmt.Errorf("duplicate options for '%s'", ToUnicode(ascii))
+			}
+			options[ascii] = option
+		}
+		domain.SubDomainOptions = options
+	}
+
+	return nil
+}
+
+// DisplayDomains returns a copy of the domains with their names converted to U-labels.
+func DisplayDomains(domains []Domain) []Domain {
+	display := make([]Domain, len(domains))
+	for i, domain := range domains {
+		domain.DomainName = ToUnicode(domain.DomainName)
+		domain.SubDomains = displayNames(domain.SubDomains)
+		domain.Hostnames = displayNames(domain.Hostnames)
+		if domain.SubDomainOptions != nil {
+			options := make(map[string]SubDomainOptions, len(domain.SubDomainOptions))
+			for name, option := range domain.SubDomainOptions {
+				options[ToUnicode(name)] = option
+			}
+			domain.SubDomainOptions = options
+		}
+		display[i] = domain
+	}
+	return display
+}
+
+func displayNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	display := make([]string, len(names))
+	for i, name := range names {
+		display[i] = ToUnicode(name)
+	}
+	return display
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
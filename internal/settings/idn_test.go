@@ -0,0 +1,91 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"example.com", "example.com", false},
+		{"Example.COM.", "example.com", false},
+		{"bücher.example", "xn--bcher-kva.example", false},
+		{"Müller.de", "xn--mller-kva.de", false},
+		{"xn--mller-kva.de", "xn--mller-kva.de", false},
+		{"*.küche", "*.xn--kche-0ra", false},
+		{"_acme-challenge.home", "_acme-challenge.home", false},
+		{"@", "@", false},
+		{"xn--zz.de", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ToASCII(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ToASCII(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ToASCII(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	if got := ToUnicode("*.xn--kche-0ra.xn--mller-kva.de"); got != "*.küche.müller.de" {
+		t.Errorf("expected the U-labels, got %q", got)
+	}
+	if got := ToUnicode("xn--zz.example.com"); got != "xn--zz.example.com" {
+		t.Errorf("expected an invalid label to be kept, got %q", got)
+	}
+	if !SameDomain("müller.de.", "xn--mller-kva.de") {
+		t.Error("expected the U-labels and A-labels of a domain to match")
+	}
+}
+
+func TestLoadSettingsNormalizesDomains(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `provider: Cloudflare
+domains:
+  - domain_name: Müller.de.
+    sub_domains: ["@", "küche", "*.büro"]
+    sub_domain_options:
+      küche:
+        ttl: 60
+  - hostnames: ["nas.bücher.example"]
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var settings Settings
+	if err := LoadSettings(path, &settings); err != nil {
+		t.Fatal(err)
+	}
+
+	domain := settings.Domains[0]
+	if domain.DomainName != "xn--mller-kva.de" {
+		t.Errorf("unexpected domain name %q", domain.DomainName)
+	}
+	if domain.SubDomains[1] != "xn--kche-0ra" || domain.SubDomains[2] != "*.xn--bro-hoa" {
+		t.Errorf("unexpected subdomains %v", domain.SubDomains)
+	}
+	if _, exists := domain.SubDomainOptions["xn--kche-0ra"]; !exists {
+		t.Errorf("expected the subdomain options to be keyed by A-label, got %v", domain.SubDomainOptions)
+	}
+	if settings.Domains[1].Hostnames[0] != "nas.xn--bcher-kva.example" {
+		t.Errorf("unexpected hostnames %v", settings.Domains[1].Hostnames)
+	}
+
+	display := DisplayDomains(settings.Domains)
+	if display[0].DomainName != "müller.de" || display[0].SubDomains[2] != "*.büro" {
+		t.Errorf("expected the U-labels for display, got %+v", display[0])
+	}
+	if settings.Domains[0].SubDomains[2] != "*.xn--bro-hoa" {
+		t.Error("expected the displayed domains to be a copy")
+	}
+}
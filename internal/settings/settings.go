@@ -3,8 +3,10 @@ package settings
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	jsoniter "github.com/json-iterator/go"
@@ -25,7 +27,45 @@ const (
 type Domain struct {
 	DomainName string   `json:"domain_name" yaml:"domain_name"`
 	SubDomains []string `json:"sub_domains" yaml:"sub_domains"`
-	Provider   string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	// Hostnames lists fully qualified hostnames instead of a domain name and its
+	// subdomains, they are split by zone on startup.
+	Hostnames []string `json:"hostnames,omitempty" yaml:"hostnames,omitempty"`
+	Provider  string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	// Interval overrides the global update interval of this domain, in seconds.
+	Interval int `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// ForceUpdateInterval overrides the global force update interval of this domain, in seconds.
+	// 0 inherits the global one and ForceUpdateDisabled disables it for this domain.
+	ForceUpdateInterval int `json:"force_update_interval,omitempty" yaml:"force_update_interval,omitempty"`
+	// Schedule is a cron expression for the updates of this domain, it takes precedence over the interval.
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	// IPType overrides the global ip_type for all the subdomains of this domain.
+	IPType string `json:"ip_type,omitempty" yaml:"ip_type,omitempty"`
+	// SubDomainOptions holds per-subdomain overrides, keyed by subdomain name.
+	SubDomainOptions map[string]SubDomainOptions `json:"sub_domain_options,omitempty" yaml:"sub_domain_options,omitempty"`
+	// RecordOptions applies to the records of all the subdomains of this domain.
+	RecordOptions `yaml:",inline"`
+}
+
+// SubDomainOptions struct for per-subdomain overrides.
+type SubDomainOptions struct {
+	IPType string `json:"ip_type,omitempty" yaml:"ip_type,omitempty"`
+	// RecordOptions overrides the record options of the domain.
+	RecordOptions `yaml:",inline"`
+}
+
+// RecordOptions holds the options of the records, applied by the providers supporting them
+// when creating or updating a record.
+type RecordOptions struct {
+	// TTL of the records in seconds, the provider default if 0.
+	TTL int `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// Proxied overrides the global proxied setting.
+	Proxied *bool `json:"proxied,omitempty" yaml:"proxied,omitempty"`
+	// Comment is attached to the records.
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	// Options holds provider-specific options, e.g. the tags of the Cloudflare records.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+	// Adopt lets the providers take over the existing records which GoDNS doesn't manage yet.
+	Adopt bool `json:"adopt,omitempty" yaml:"adopt,omitempty"`
 }
 
 // SlackNotify struct for Slack notification.
@@ -106,6 +146,10 @@ type NtfyNotify struct {
 
 // ProviderConfig holds provider-specific configuration.
 type ProviderConfig struct {
+	// Type is the provider type, e.g. Cloudflare, which defaults to the name of
+	// the provider so that several accounts of the same type can be configured.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
 	// Common fields across providers
 	Email          string `json:"email,omitempty" yaml:"email,omitempty"`
 	Password       string `json:"password,omitempty" yaml:"password,omitempty"`
@@ -144,6 +188,30 @@ type WebPanel struct {
 	Password string `json:"password" yaml:"password"`
 }
 
+// Retry struct for the retry policy of failed record updates.
+type Retry struct {
+	// MaxAttempts is the max number of attempts per record in a single update run, 1 disables retries.
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts"`
+	// BaseDelay is the delay before the first retry in seconds, doubled on each retry.
+	BaseDelay int `json:"base_delay" yaml:"base_delay"`
+	// MaxDelay is the upper bound of the delay between two attempts in seconds.
+	MaxDelay int `json:"max_delay" yaml:"max_delay"`
+	// Jitter randomizes each delay by up to the given fraction, e.g. 0.2 for +/-20%.
+	Jitter float64 `json:"jitter" yaml:"jitter"`
+	// Timeout bounds each attempt in seconds, 60 by default.
+	Timeout int `json:"timeout" yaml:"timeout"`
+}
+
+// Scheduler struct for the scheduling of the domain updates.
+type Scheduler struct {
+	// Concurrency is the max number of domains updated at the same time, 0 means no limit.
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+	// ProviderConcurrency is the max number of domains updated at the same time per provider.
+	ProviderConcurrency map[string]int `json:"provider_concurrency,omitempty" yaml:"provider_concurrency,omitempty"`
+	// StartupJitter spreads the first updates of the domains over the given number of seconds.
+	StartupJitter int `json:"startup_jitter" yaml:"startup_jitter"`
+}
+
 type Mikrotik struct {
 	Enabled   bool   `json:"enabled" yaml:"enabled"`
 	Addr      string `json:"addr" yaml:"addr"`
@@ -182,20 +250,33 @@ type Settings struct {
 	Resolver     string   `json:"resolver" yaml:"resolver"`
 
 	// Application configuration
-	Interval      int    `json:"interval" yaml:"interval"`
-	UserAgent     string `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
-	Socks5Proxy   string `json:"socks5_proxy" yaml:"socks5_proxy"`
-	UseProxy      bool   `json:"use_proxy" yaml:"use_proxy"`
-	DebugInfo     bool   `json:"debug_info" yaml:"debug_info"`
-	RunOnce       bool   `json:"run_once" yaml:"run_once"`
-	Proxied       bool   `json:"proxied" yaml:"proxied"`
-	SkipSSLVerify bool   `json:"skip_ssl_verify" yaml:"skip_ssl_verify"`
+	Interval int `json:"interval" yaml:"interval"`
+	// ForceUpdateInterval is the max time in seconds between two provider updates of a record, even if its IP is unchanged.
+	ForceUpdateInterval int    `json:"force_update_interval,omitempty" yaml:"force_update_interval,omitempty"`
+	UserAgent           string `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
+	Socks5Proxy         string `json:"socks5_proxy" yaml:"socks5_proxy"`
+	UseProxy            bool   `json:"use_proxy" yaml:"use_proxy"`
+	DebugInfo           bool   `json:"debug_info" yaml:"debug_info"`
+	RunOnce             bool   `json:"run_once" yaml:"run_once"`
+	DryRun              bool   `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+	StateFile           string `json:"state_file,omitempty" yaml:"state_file,omitempty"`
+	ShutdownTimeout     int    `json:"shutdown_timeout,omitempty" yaml:"shutdown_timeout,omitempty"`
+	Proxied             bool   `json:"proxied" yaml:"proxied"`
+	Adopt               bool   `json:"adopt,omitempty" yaml:"adopt,omitempty"`
+	Prune               bool   `json:"prune,omitempty" yaml:"prune,omitempty"`
+	SkipSSLVerify       bool   `json:"skip_ssl_verify" yaml:"skip_ssl_verify"`
 
 	// Feature configuration
-	Notify   Notify   `json:"notify" yaml:"notify"`
-	Webhook  Webhook  `json:"webhook,omitempty" yaml:"webhook,omitempty"`
-	Mikrotik Mikrotik `json:"mikrotik" yaml:"mikrotik"`
-	WebPanel WebPanel `json:"web_panel" yaml:"web_panel"`
+	Notify    Notify    `json:"notify" yaml:"notify"`
+	Webhook   Webhook   `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+	Retry     Retry     `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Scheduler Scheduler `json:"scheduler,omitempty" yaml:"scheduler,omitempty"`
+	Mikrotik  Mikrotik  `json:"mikrotik" yaml:"mikrotik"`
+	WebPanel  WebPanel  `json:"web_panel" yaml:"web_panel"`
+
+	// configuredDomains holds the domains as configured once their hostnames are
+	// expanded into Domains, they are the ones saved to the configuration file.
+	configuredDomains []Domain
 }
 
 // LoadSettings -- Load settings from config file.
@@ -236,11 +317,39 @@ func LoadSettings(configPath string, settings *Settings) error {
 		return errors.New("invalid extension for config file:" + fileExt)
 	}
 
+	for i := range settings.Domains {
+		if err := NormalizeDomain(&settings.Domains[i]); err != nil {
+			return err
+		}
+	}
+
 	if settings.Interval == 0 {
 		// set default interval as 5 minutes if interval is 0
 		settings.Interval = 5 * 60
 	}
 
+	if settings.ShutdownTimeout == 0 {
+		// wait up to 30 seconds for the in-flight updates on shutdown
+		settings.ShutdownTimeout = 30
+	}
+
+	if settings.Retry.MaxAttempts == 0 {
+		// retries are disabled by default
+		settings.Retry.MaxAttempts = 1
+	}
+
+	if settings.Retry.BaseDelay == 0 {
+		settings.Retry.BaseDelay = 5
+	}
+
+	if settings.Retry.MaxDelay == 0 {
+		settings.Retry.MaxDelay = 5 * 60
+	}
+
+	if settings.Retry.Timeout == 0 {
+		settings.Retry.Timeout = 60
+	}
+
 	if err := loadSecretsFromFile(settings); err != nil {
 		return err
 	}
@@ -264,20 +373,23 @@ func (s *Settings) SaveSettings(configPath string) error {
 		return errors.New("invalid config file name")
 	}
 
-	// LoadSettings from config file
+	// the hostnames are saved as configured, not split by zone
+	saved := *s
+	saved.Domains = s.ConfiguredDomains()
+
 	var content []byte
 	var err error
 
 	switch fileExt {
 	case extJSON:
-		content, err = json.MarshalIndent(s, "", "  ")
+		content, err = json.MarshalIndent(&saved, "", "  ")
 		if err != nil {
 			return err
 		}
 	case extYML:
 		fallthrough
 	case extYAML:
-		content, err = yaml.Marshal(s)
+		content, err = yaml.Marshal(&saved)
 		if err != nil {
 			return err
 		}
@@ -380,6 +492,14 @@ func (s *Settings) GetProviderConfig(providerName string) *ProviderConfig {
 	}
 }
 
+// GetProviderType returns the type of a provider, e.g. Cloudflare for a provider named cf-work.
+func (s *Settings) GetProviderType(providerName string) string {
+	if providerConfig, exists := s.Providers[providerName]; exists && providerConfig.Type != "" {
+		return providerConfig.Type
+	}
+	return providerName
+}
+
 // GetDomainProvider returns the provider for a specific domain.
 // Falls back to the global provider if domain doesn't specify one.
 func (s *Settings) GetDomainProvider(domain *Domain) string {
@@ -389,6 +509,141 @@ func (s *Settings) GetDomainProvider(domain *Domain) string {
 	return s.Provider
 }
 
+// GetDomainInterval returns the update interval of a specific domain in seconds.
+// Falls back to the global interval if domain doesn't specify one.
+func (s *Settings) GetDomainInterval(domain *Domain) int {
+	if domain.Interval > 0 {
+		return domain.Interval
+	}
+	return s.Interval
+}
+
+// ForceUpdateDisabled is the force update interval of a domain opting out of the global one.
+const ForceUpdateDisabled = -1
+
+// GetDomainForceUpdateInterval returns the force update interval of a specific domain in seconds, 0 if disabled.
+// Falls back to the global force update interval if domain doesn't specify one.
+func (s *Settings) GetDomainForceUpdateInterval(domain *Domain) int {
+	switch {
+	case domain.ForceUpdateInterval == ForceUpdateDisabled:
+		return 0
+	case domain.ForceUpdateInterval > 0:
+		return domain.ForceUpdateInterval
+	}
+	return s.ForceUpdateInterval
+}
+
+// GetMinInterval returns the shortest update interval of all the domains in seconds.
+func (s *Settings) GetMinInterval() int {
+	interval := s.Interval
+	for i := range s.Domains {
+		if domainInterval := s.GetDomainInterval(&s.Domains[i]); domainInterval < interval {
+			interval = domainInterval
+		}
+	}
+	return interval
+}
+
+// GetSubDomainIPType returns the ip_type for a specific subdomain.
+// Falls back to the domain ip_type, then to the global ip_type.
+func (s *Settings) GetSubDomainIPType(domain *Domain, subDomain string) string {
+	if options, exists := domain.SubDomainOptions[subDomain]; exists && options.IPType != "" {
+		return options.IPType
+	}
+
+	if domain.IPType != "" {
+		return domain.IPType
+	}
+
+	return s.IPType
+}
+
+// GetRecordOptions returns the record options of a subdomain, merged with the
+// options of its domain and the global adopt setting. The global proxied setting
+// isn't merged, as the providers only apply it to the records they create.
+func (s *Settings) GetRecordOptions(domainName, subDomain string) RecordOptions {
+	var options RecordOptions
+	for i := range s.Domains {
+		if s.Domains[i].DomainName != domainName {
+			continue
+		}
+
+		domain := &s.Domains[i]
+		options = domain.RecordOptions
+		options.Options = maps.Clone(domain.Options)
+
+		override, exists := domain.SubDomainOptions[subDomain]
+		if !exists {
+			break
+		}
+		if override.TTL != 0 {
+			options.TTL = override.TTL
+		}
+		if override.Proxied != nil {
+			options.Proxied = override.Proxied
+		}
+		if override.Comment != "" {
+			options.Comment = override.Comment
+		}
+		if override.Adopt {
+			options.Adopt = true
+		}
+		if len(override.Options) > 0 {
+			if options.Options == nil {
+				options.Options = make(map[string]string, len(override.Options))
+			}
+			maps.Copy(options.Options, override.Options)
+		}
+		break
+	}
+
+	options.Adopt = options.Adopt || s.Adopt
+	return options
+}
+
+// ConfiguredDomains returns the domains as configured, before their hostnames are expanded.
+func (s *Settings) ConfiguredDomains() []Domain {
+	if s.configuredDomains != nil {
+		return s.configuredDomains
+	}
+	return s.Domains
+}
+
+// SetExpandedDomains replaces the domains by the ones expanded from their
+// hostnames, keeping the configured ones to be saved.
+func (s *Settings) SetExpandedDomains(domains []Domain) {
+	if s.configuredDomains == nil {
+		s.configuredDomains = s.Domains
+	}
+	s.Domains = domains
+}
+
+// SetConfiguredDomains replaces the configured domains, e.g. edited in the web panel.
+func (s *Settings) SetConfiguredDomains(domains []Domain) {
+	s.Domains = domains
+	s.configuredDomains = nil
+}
+
+// Clone returns a copy of the configuration which can be edited without changing
+// this one: the domains, the providers and the provider concurrency are copied.
+func (s *Settings) Clone() *Settings {
+	clone := *s
+	clone.Domains = slices.Clone(s.Domains)
+	clone.configuredDomains = slices.Clone(s.configuredDomains)
+	if s.Providers != nil {
+		clone.Providers = make(map[string]*ProviderConfig, len(s.Providers))
+		for name, config := range s.Providers {
+			if config != nil {
+				providerConfig := *config
+				config = &providerConfig
+			}
+			clone.Providers[name] = config
+		}
+	}
+	clone.Scheduler.ProviderConcurrency = maps.Clone(s.Scheduler.ProviderConcurrency)
+	return &clone
+}
+
 // IsMultiProvider returns true if the configuration uses multiple providers.
 func (s *Settings) IsMultiProvider() bool {
 	return len(s.Providers) > 0
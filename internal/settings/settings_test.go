@@ -3,6 +3,8 @@ package settings
 import (
 	"os"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoadJSONSetting(t *testing.T) {
@@ -81,3 +83,50 @@ func TestLoadWithEnvPath(t *testing.T) {
 
 	t.Log(settings)
 }
+
+func TestGetRecordOptions(t *testing.T) {
+	var conf Settings
+	content := []byte(`
+domains:
+  - domain_name: example.com
+    sub_domains: ["www", "vpn"]
+    ttl: 300
+    comment: managed by GoDNS
+    options:
+      tags: home
+    sub_domain_options:
+      vpn:
+        ttl: 60
+        proxied: false
+        options:
+          region: eu
+`)
+	if err := yaml.Unmarshal(content, &conf); err != nil {
+		t.Fatalf("failed to parse the settings: %s", err)
+	}
+
+	options := conf.GetRecordOptions("example.com", "www")
+	if options.TTL != 300 || options.Proxied != nil || options.Comment != "managed by GoDNS" || options.Options["tags"] != "home" {
+		t.Errorf("unexpected options of www: %+v", options)
+	}
+
+	options = conf.GetRecordOptions("example.com", "vpn")
+	if options.TTL != 60 || options.Proxied == nil || *options.Proxied || options.Comment != "managed by GoDNS" {
+		t.Errorf("unexpected options of vpn: %+v", options)
+	}
+	if options.Options["tags"] != "home" || options.Options["region"] != "eu" {
+		t.Errorf("expected the options to be merged, got %v", options.Options)
+	}
+	if len(conf.Domains[0].Options) != 1 {
+		t.Errorf("expected the domain options to be left untouched, got %v", conf.Domains[0].Options)
+	}
+
+	// the record options are inlined in the JSON configuration as well
+	var jsonConf Settings
+	if err := json.Unmarshal([]byte(`{"domains": [{"domain_name": "example.com", "ttl": 120}]}`), &jsonConf); err != nil {
+		t.Fatalf("failed to parse the settings: %s", err)
+	}
+	if options := jsonConf.GetRecordOptions("example.com", "www"); options.TTL != 120 {
+		t.Errorf("expected a TTL of 120, got %d", options.TTL)
+	}
+}
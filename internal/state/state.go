@@ -0,0 +1,341 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RecordState holds the update state of a single DNS record.
+type RecordState struct {
+	Hostname   string `json:"hostname"`
+	RecordType string `json:"record_type"`
+	// Value is the last value successfully pushed to (or confirmed on) the provider.
+	Value       string    `json:"value"`
+	LastSuccess time.Time `json:"last_success"`
+	// LastPush is the time of the last successful update call to the provider.
+	LastPush    time.Time `json:"last_push"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+	// Attempts counts the failed attempts since the last success.
+	Attempts int `json:"attempts"`
+	// Created is true if the record was missing at the provider and created by GoDNS.
+	Created bool `json:"created,omitempty"`
+	// Adopted is true if the existing record was taken over by GoDNS with adopt.
+	Adopted bool `json:"adopted,omitempty"`
+	// Status is the outcome of the last provider call: created, updated or failed.
+	Status string `json:"status,omitempty"`
+	// StatusCode is the HTTP status returned by the provider on the last failure, if known.
+	StatusCode int `json:"status_code,omitempty"`
+}
+
+// Owned returns true if the record is managed by GoDNS: created or adopted by it.
+func (r RecordState) Owned() bool {
+	return r.Created || r.Adopted
+}
+
+// The outcomes of a provider call, see RecordState.Status.
+const (
+	StatusCreated = "created"
+	StatusUpdated = "updated"
+	StatusFailed  = "failed"
+)
+
+// Store keeps the update state of every managed DNS record,
+// keyed by hostname and record type. It is safe for concurrent use.
+type Store struct {
+	mutex   sync.RWMutex
+	records map[string]*RecordState
+	// inFlight counts the updates in progress per record, it is never saved
+	inFlight map[string]int
+	// pushRequested is the time of the last request to push all the records
+	pushRequested time.Time
+	// path of the state file, the store is kept in memory only if it's empty
+	path string
+}
+
+// stateFile is the on-disk format of the state file.
+type stateFile struct {
+	Records []RecordState `json:"records"`
+}
+
+// NewStore creates an empty in-memory state store.
+func NewStore() *Store {
+	return &Store{
+		records:  map[string]*RecordState{},
+		inFlight: map[string]int{},
+	}
+}
+
+// LoadStore creates a state store backed by the given file. The previous
+// state is loaded from the file if it exists, and every change is written
+// back to it.
+func LoadStore(path string) (*Store, error) {
+	store := NewStore()
+	store.path = path
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return store, nil
+		}
+		return store, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return store, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+
+	for i := range file.Records {
+		record := file.Records[i]
+		store.records[Key(record.Hostname, record.RecordType)] = &record
+	}
+
+	log.Debugf("Loaded the state of %d records from %s", len(store.records), path)
+	return store, nil
+}
+
+// Path returns the path of the state file, or an empty string for an in-memory store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Key returns the key of a record in the store, e.g. "www.example.com/AAAA".
+func Key(hostname, recordType string) string {
+	return hostname + "/" + recordType
+}
+
+// Get returns a copy of the state of a record.
+func (s *Store) Get(hostname, recordType string) (RecordState, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, exists := s.records[Key(hostname, recordType)]
+	if !exists {
+		return RecordState{}, false
+	}
+
+	return *record, true
+}
+
+// IsUpToDate returns true if the last update of a record succeeded with the given value.
+func (s *Store) IsUpToDate(hostname, recordType, value string) bool {
+	record, exists := s.Get(hostname, recordType)
+	return exists && record.LastError == "" && record.Value == value
+}
+
+// RecordSuccess marks a record as holding the given value, e.g. after a DNS lookup.
+func (s *Store) RecordSuccess(hostname, recordType, value string) {
+	s.recordSuccess(hostname, recordType, value, false)
+}
+
+// RecordPush marks a record as successfully updated to the given value by the provider.
+func (s *Store) RecordPush(hostname, recordType, value string) {
+	s.recordSuccess(hostname, recordType, value, true)
+}
+
+func (s *Store) recordSuccess(hostname, recordType, value string, pushed bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	record := s.getOrCreate(hostname, recordType)
+	record.Value = value
+	record.LastSuccess = now
+	if pushed {
+		record.LastPush = now
+		record.Status = StatusUpdated
+		record.StatusCode = 0
+	}
+	record.LastAttempt = now
+	record.LastError = ""
+	record.Attempts = 0
+	s.save()
+}
+
+// RecordCreated marks a record as created by GoDNS, so that it may be pruned.
+func (s *Store) RecordCreated(hostname, recordType string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record := s.getOrCreate(hostname, recordType)
+	record.Created = true
+	record.Status = StatusCreated
+	s.save()
+}
+
+// RecordAdopted marks an existing record as taken over by GoDNS, so that it
+// is managed like the records it created.
+func (s *Store) RecordAdopted(hostname, recordType string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record := s.getOrCreate(hostname, recordType)
+	if record.Adopted {
+		return
+	}
+	record.Adopted = true
+	s.save()
+}
+
+// RecordFailure marks a failed update attempt of a record, with the HTTP
+// status returned by the provider or zero if unknown.
+func (s *Store) RecordFailure(hostname, recordType string, statusCode int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	record := s.getOrCreate(hostname, recordType)
+	record.LastAttempt = time.Now()
+	record.LastError = err.Error()
+	record.Status = StatusFailed
+	record.StatusCode = statusCode
+	record.Attempts++
+	s.save()
+}
+
+// Delete forgets the state of a record, e.g. once it is deleted from the provider.
+func (s *Store) Delete(hostname, recordType string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.records[Key(hostname, recordType)]; !exists {
+		return
+	}
+	delete(s.records, Key(hostname, recordType))
+	s.save()
+}
+
+// RequestPush requests a provider update of every record, even the ones up to date.
+func (s *Store) RequestPush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pushRequested = time.Now()
+}
+
+// IsPushRequested returns true if a provider update of the record was requested since its last push.
+func (s *Store) IsPushRequested(hostname, recordType string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.pushRequested.IsZero() {
+		return false
+	}
+
+	record, exists := s.records[Key(hostname, recordType)]
+	return !exists || record.LastPush.Before(s.pushRequested)
+}
+
+// StartUpdate marks the update of a record as in progress, until the returned function is called.
+func (s *Store) StartUpdate(hostname, recordType string) func() {
+	key := Key(hostname, recordType)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inFlight[key]++
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.inFlight[key]--; s.inFlight[key] <= 0 {
+			delete(s.inFlight, key)
+		}
+	}
+}
+
+// InFlight returns the keys of the records whose update is in progress, sorted.
+func (s *Store) InFlight() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := make([]string, 0, len(s.inFlight))
+	for key := range s.inFlight {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// List returns a copy of all the record states, sorted by key.
+func (s *Store) List() []RecordState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.list()
+}
+
+// list returns a copy of all the record states, the caller must hold the lock.
+func (s *Store) list() []RecordState {
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]RecordState, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, *s.records[key])
+	}
+
+	return records
+}
+
+// save writes the state to the state file, the caller must hold the write lock.
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+
+	content, err := json.MarshalIndent(stateFile{Records: s.list()}, "", "  ")
+	if err != nil {
+		log.Errorf("Failed to encode state: %s", err)
+		return
+	}
+
+	// write to a temporary file first, so that a crash never leaves a truncated state file
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		log.Errorf("Failed to save state file %s: %s", s.path, err)
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		log.Errorf("Failed to save state file %s: %s", s.path, err)
+		return
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		log.Errorf("Failed to save state file %s: %s", s.path, err)
+		return
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		log.Errorf("Failed to save state file %s: %s", s.path, err)
+	}
+}
+
+func (s *Store) getOrCreate(hostname, recordType string) *RecordState {
+	key := Key(hostname, recordType)
+	record, exists := s.records[key]
+	if !exists {
+		record = &RecordState{
+			Hostname:   hostname,
+			RecordType: recordType,
+		}
+		s.records[key] = record
+	}
+
+	return record
+}
@@ -0,0 +1,167 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRecordSuccessAndFailure(t *testing.T) {
+	store := NewStore()
+
+	if store.IsUpToDate("www.example.com", "A", "1.2.3.4") {
+		t.Fatal("unknown record should not be up to date")
+	}
+
+	store.RecordSuccess("www.example.com", "A", "1.2.3.4")
+	if !store.IsUpToDate("www.example.com", "A", "1.2.3.4") {
+		t.Error("record should be up to date after a successful update")
+	}
+	if store.IsUpToDate("www.example.com", "A", "5.6.7.8") {
+		t.Error("record should not be up to date for a different IP")
+	}
+	if store.IsUpToDate("www.example.com", "AAAA", "1.2.3.4") {
+		t.Error("records of different types must be tracked separately")
+	}
+
+	store.RecordFailure("www.example.com", "A", 0, errors.New("provider error"))
+	store.RecordFailure("www.example.com", "A", 0, errors.New("provider error"))
+
+	record, exists := store.Get("www.example.com", "A")
+	if !exists {
+		t.Fatal("record should exist")
+	}
+	if record.Attempts != 2 {
+		t.Errorf("expected 2 failed attempts, got %d", record.Attempts)
+	}
+	if record.LastError != "provider error" {
+		t.Errorf("unexpected last error: %q", record.LastError)
+	}
+	if record.Value != "1.2.3.4" {
+		t.Errorf("failure must keep the last pushed value, got %q", record.Value)
+	}
+	if store.IsUpToDate("www.example.com", "A", "1.2.3.4") {
+		t.Error("failed record must be retried even if the IP didn't change")
+	}
+
+	store.RecordSuccess("www.example.com", "A", "5.6.7.8")
+	record, _ = store.Get("www.example.com", "A")
+	if record.Attempts != 0 || record.LastError != "" {
+		t.Errorf("success should reset the failure state, got %+v", record)
+	}
+}
+
+func TestRecordPush(t *testing.T) {
+	store := NewStore()
+
+	store.RecordSuccess("www.example.com", "A", "1.2.3.4")
+	record, _ := store.Get("www.example.com", "A")
+	if !record.LastPush.IsZero() {
+		t.Error("a record confirmed by a DNS lookup should not have a push time")
+	}
+
+	store.RecordPush("www.example.com", "A", "1.2.3.4")
+	record, _ = store.Get("www.example.com", "A")
+	if record.LastPush.IsZero() || !record.LastPush.Equal(record.LastSuccess) {
+		t.Errorf("expected the push time to be the success time, got %+v", record)
+	}
+	if record.Status != StatusUpdated {
+		t.Errorf("expected the updated status, got %q", record.Status)
+	}
+
+	store.RecordCreated("www.example.com", "A")
+	record, _ = store.Get("www.example.com", "A")
+	if !record.Created || record.Status != StatusCreated {
+		t.Errorf("expected the record to be created, got %+v", record)
+	}
+}
+
+func TestRequestPush(t *testing.T) {
+	store := NewStore()
+	store.RecordPush("www.example.com", "A", "1.2.3.4")
+
+	if store.IsPushRequested("www.example.com", "A") {
+		t.Error("no push should be requested by default")
+	}
+
+	store.RequestPush()
+	if !store.IsPushRequested("www.example.com", "A") || !store.IsPushRequested("api.example.com", "A") {
+		t.Error("a push should be requested for every record")
+	}
+
+	store.RecordPush("www.example.com", "A", "1.2.3.4")
+	if store.IsPushRequested("www.example.com", "A") {
+		t.Error("the push request should be fulfilled by a push")
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	store := NewStore()
+
+	done := store.StartUpdate("www.example.com", "A")
+	store.StartUpdate("api.example.com", "AAAA")
+
+	if keys := store.InFlight(); !slices.Equal(keys, []string{"api.example.com/AAAA", "www.example.com/A"}) {
+		t.Errorf("unexpected in-flight records: %v", keys)
+	}
+
+	done()
+	if keys := store.InFlight(); !slices.Equal(keys, []string{"api.example.com/AAAA"}) {
+		t.Errorf("unexpected in-flight records after the update finished: %v", keys)
+	}
+}
+
+func TestList(t *testing.T) {
+	store := NewStore()
+	store.RecordSuccess("b.example.com", "A", "1.2.3.4")
+	store.RecordSuccess("a.example.com", "AAAA", "2001:db8::1")
+
+	records := store.List()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Hostname != "a.example.com" || records[1].Hostname != "b.example.com" {
+		t.Errorf("records should be sorted by key, got %v", records)
+	}
+}
+
+func TestLoadStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	store, err := LoadStore(path)
+	if err != nil {
+		t.Fatalf("missing state file should not be an error: %v", err)
+	}
+	store.RecordSuccess("www.example.com", "A", "1.2.3.4")
+	store.RecordFailure("nas.example.com", "AAAA", 0, errors.New("provider error"))
+
+	// a new store reading the same file must restore the state
+	reloaded, err := LoadStore(path)
+	if err != nil {
+		t.Fatalf("LoadStore: %v", err)
+	}
+	if !reloaded.IsUpToDate("www.example.com", "A", "1.2.3.4") {
+		t.Error("record should be up to date after reloading the state file")
+	}
+	record, exists := reloaded.Get("nas.example.com", "AAAA")
+	if !exists || record.Attempts != 1 || record.LastError != "provider error" {
+		t.Errorf("failure state not restored, got %+v", record)
+	}
+}
+
+func TestLoadStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadStore(path)
+	if err == nil {
+		t.Error("invalid state file should return an error")
+	}
+	if store == nil || len(store.List()) != 0 {
+		t.Error("an empty store should be returned for an invalid state file")
+	}
+}
@@ -6,4 +6,6 @@ import (
 
 var (
 	ErrUnknownProvider = errors.New("unknown provider")
+	// ErrNotOwned is returned by the providers refusing to modify a record which GoDNS doesn't manage.
+	ErrNotOwned = errors.New("record not managed by GoDNS")
 )
@@ -3,8 +3,11 @@ package utils
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/TimothyYe/godns/internal/settings"
@@ -53,3 +56,74 @@ func GetHTTPClient(conf *settings.Settings) *http.Client {
 	client.Transport = httpTransport
 	return client
 }
+
+// RetryAfterError is returned by providers when the API asks the client to
+// slow down, e.g. with a 429 Too Many Requests response.
+type RetryAfterError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the Retry-After header, zero if absent.
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited with status %d, retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limited with status %d", e.StatusCode)
+}
+
+// CheckRetryAfter returns a RetryAfterError if the response is a 429 Too Many
+// Requests or a 503 Service Unavailable, nil otherwise.
+func CheckRetryAfter(resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+
+	return &RetryAfterError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// CheckStatusCode returns a RetryAfterError if the status code reported by a
+// provider SDK is a 429 or a 503, nil otherwise. The SDKs don't expose the
+// Retry-After header, so the retry policy falls back to its own backoff.
+func CheckStatusCode(statusCode int) error {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+
+	return &RetryAfterError{StatusCode: statusCode}
+}
+
+// StatusCode returns the HTTP status of a rate limit error, zero for any other error.
+func StatusCode(err error) int {
+	var retryAfterErr *RetryAfterError
+	if errors.As(err, &retryAfterErr) {
+		return retryAfterErr.StatusCode
+	}
+	return 0
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, either a number
+// of seconds or an HTTP date. Returns zero if the value is empty or invalid.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := ParseRetryAfter("120"); got != 120*time.Second {
+		t.Errorf("expected 120s, got %s", got)
+	}
+
+	if got := ParseRetryAfter(""); got != 0 {
+		t.Errorf("expected 0 for an empty header, got %s", got)
+	}
+
+	if got := ParseRetryAfter("soon"); got != 0 {
+		t.Errorf("expected 0 for an invalid header, got %s", got)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(date); got <= 0 || got > time.Minute {
+		t.Errorf("expected a delay up to 1m for an HTTP date, got %s", got)
+	}
+}
+
+func TestCheckRetryAfter(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	if err := CheckRetryAfter(resp); err != nil {
+		t.Errorf("expected no error for a 200 response, got %v", err)
+	}
+
+	resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}}
+	err := CheckRetryAfter(resp)
+	retryAfterErr, ok := err.(*RetryAfterError)
+	if !ok {
+		t.Fatalf("expected a RetryAfterError, got %v", err)
+	}
+	if retryAfterErr.RetryAfter != 7*time.Second {
+		t.Errorf("expected 7s, got %s", retryAfterErr.RetryAfter)
+	}
+}
+
+func TestCheckStatusCode(t *testing.T) {
+	var retryAfterErr *RetryAfterError
+	if err := CheckStatusCode(http.StatusTooManyRequests); !errors.As(err, &retryAfterErr) || retryAfterErr.RetryAfter != 0 {
+		t.Errorf("expected a RetryAfterError without delay, got %v", err)
+	}
+	if err := CheckStatusCode(http.StatusBadRequest); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -1,13 +1,86 @@
 package utils
 
 import (
+	"errors"
 	"net"
 	"strings"
 
+	"github.com/TimothyYe/godns/internal/settings"
 	dnsResolver "github.com/TimothyYe/godns/pkg/resolver"
 	"github.com/miekg/dns"
 )
 
+// GetIPTypes returns the IP families selected by the ip_type setting.
+// An empty value defaults to IPV4, DUAL selects both families.
+func GetIPTypes(ipType string) []string {
+	switch strings.ToUpper(ipType) {
+	case IPV6:
+		return []string{IPV6}
+	case DUAL:
+		return []string{IPV4, IPV6}
+	default:
+		return []string{IPV4}
+	}
+}
+
+// GetConfiguredIPTypes returns all the IP families required by the configured domains,
+// taking the per-domain and per-subdomain ip_type overrides into account.
+func GetConfiguredIPTypes(conf *settings.Settings) []string {
+	if len(conf.Domains) == 0 {
+		return GetIPTypes(conf.IPType)
+	}
+
+	var ipTypes []string
+	for i := range conf.Domains {
+		ipTypes = append(ipTypes, GetDomainIPTypes(conf, &conf.Domains[i])...)
+	}
+
+	return sortIPTypes(ipTypes)
+}
+
+// GetDomainIPTypes returns the IP families used by the subdomains of a domain.
+func GetDomainIPTypes(conf *settings.Settings, domain *settings.Domain) []string {
+	var ipTypes []string
+	for _, subDomain := range domain.SubDomains {
+		ipTypes = append(ipTypes, GetIPTypes(conf.GetSubDomainIPType(domain, subDomain))...)
+	}
+
+	return sortIPTypes(ipTypes)
+}
+
+// sortIPTypes removes duplicated families and returns IPV4 before IPV6.
+func sortIPTypes(ipTypes []string) []string {
+	var sorted []string
+	for _, family := range []string{IPV4, IPV6} {
+		for _, ipType := range ipTypes {
+			if ipType == family {
+				sorted = append(sorted, family)
+				break
+			}
+		}
+	}
+
+	return sorted
+}
+
+// GetIPType returns the IP family (IPV4 or IPV6) of the given address.
+func GetIPType(ip string) string {
+	if strings.Count(ip, ":") < 2 {
+		return IPV4
+	}
+
+	return IPV6
+}
+
+// GetRecordType returns the DNS record type (A or AAAA) for the given address.
+func GetRecordType(ip string) string {
+	if GetIPType(ip) == IPV6 {
+		return IPTypeAAAA
+	}
+
+	return IPTypeA
+}
+
 // ResolveDNS will query DNS for a given hostname.
 func ResolveDNS(hostname, resolver, ipType string) (string, error) {
 	var dnsType uint16
@@ -24,6 +97,14 @@ func ResolveDNS(hostname, resolver, ipType string) (string, error) {
 			return "<nil>", err
 		}
 
+		// prefer an address of the requested family, the system resolver
+		// returns both A and AAAA results
+		for _, addr := range dnsAddress {
+			if (dnsType == dns.TypeA) == (GetIPType(addr) == IPV4) {
+				return addr, nil
+			}
+		}
+
 		return dnsAddress[0], nil
 	}
 	res := dnsResolver.New([]string{resolver})
@@ -37,3 +118,12 @@ func ResolveDNS(hostname, resolver, ipType string) (string, error) {
 
 	return ip[0].String(), nil
 }
+
+// IsNotFound returns true if the error of ResolveDNS means that the host has no such record.
+func IsNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+	return errors.Is(err, dnsResolver.ErrNotFound)
+}
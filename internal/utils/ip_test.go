@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	dnsResolver "github.com/TimothyYe/godns/pkg/resolver"
+)
+
+func TestGetIPTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		ipType string
+		want   []string
+	}{
+		{"empty defaults to IPv4", "", []string{IPV4}},
+		{"IPv4", "IPv4", []string{IPV4}},
+		{"IPv6", "IPv6", []string{IPV6}},
+		{"dual", "dual", []string{IPV4, IPV6}},
+		{"DUAL", "DUAL", []string{IPV4, IPV6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetIPTypes(tt.ipType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetIPTypes(%q) = %v, want %v", tt.ipType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecordType(t *testing.T) {
+	tests := []struct {
+		ip         string
+		wantType   string
+		wantRecord string
+	}{
+		{"1.2.3.4", IPV4, IPTypeA},
+		{"2001:db8::1", IPV6, IPTypeAAAA},
+		{"::ffff:1.2.3.4", IPV6, IPTypeAAAA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := GetIPType(tt.ip); got != tt.wantType {
+				t.Errorf("GetIPType(%q) = %v, want %v", tt.ip, got, tt.wantType)
+			}
+			if got := GetRecordType(tt.ip); got != tt.wantRecord {
+				t.Errorf("GetRecordType(%q) = %v, want %v", tt.ip, got, tt.wantRecord)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"system resolver", &net.DNSError{Err: "no such host", IsNotFound: true}, true},
+		{"system resolver timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, false},
+		{"custom resolver", fmt.Errorf("%w: NXDOMAIN", dnsResolver.ErrNotFound), true},
+		{"custom resolver failure", errors.New("SERVFAIL"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.want {
+				t.Errorf("IsNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
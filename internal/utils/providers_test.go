@@ -0,0 +1,4 @@
+package utils_test
+
+// the settings tests validate the credentials of the registered providers
+import _ "github.com/TimothyYe/godns/internal/provider"
@@ -3,15 +3,38 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
+	"github.com/TimothyYe/godns/internal/scheduler"
 	"github.com/TimothyYe/godns/internal/settings"
 )
 
 // CheckSettings check the format of settings.
 func CheckSettings(config *settings.Settings) error {
+	if err := checkRetry(&config.Retry); err != nil {
+		return err
+	}
+
+	if err := checkScheduler(config); err != nil {
+		return err
+	}
+
+	if err := checkForceUpdateIntervals(config); err != nil {
+		return err
+	}
+
+	if config.ShutdownTimeout < 0 {
+		return errors.New("shutdown_timeout should not be negative")
+	}
+
 	// Check if it's multi-provider mode
 	if config.IsMultiProvider() {
-		return checkMultiProviderSettings(config)
+		if err := checkMultiProviderSettings(config); err != nil {
+			return err
+		}
+		return checkCapabilities(config)
 	}
 
 	// Legacy single provider mode validation
@@ -23,7 +46,67 @@ func CheckSettings(config *settings.Settings) error {
 		return err
 	}
 
-	return checkDomains(config)
+	if err := checkDomains(config); err != nil {
+		return err
+	}
+
+	return checkCapabilities(config)
+}
+
+// checkCapabilities rejects the options which the providers of the domains don't support.
+func checkCapabilities(config *settings.Settings) error {
+	proxied := false
+	for i := range config.Domains {
+		domain := &config.Domains[i]
+		providerName := config.GetDomainProvider(domain)
+		provider, exists := registry.Get(config.GetProviderType(providerName))
+		if !exists {
+			continue
+		}
+
+		if !provider.Capabilities.IPv6 && slices.Contains(GetDomainIPTypes(config, domain), IPV6) {
+			return fmt.Errorf("domain %s: provider %s doesn't support IPv6 (AAAA) records", domain.DomainName, providerName)
+		}
+
+		if !provider.Capabilities.Wildcard && slices.ContainsFunc(domain.SubDomains, isWildcard) {
+			return fmt.Errorf("domain %s: provider %s doesn't support wildcard subdomains", domain.DomainName, providerName)
+		}
+
+		if err := checkRecordOptions(&domain.RecordOptions, provider.Capabilities); err != nil {
+			return fmt.Errorf("domain %s: %w with provider %s", domain.DomainName, err, providerName)
+		}
+		for sd, options := range domain.SubDomainOptions {
+			if err := checkRecordOptions(&options.RecordOptions, provider.Capabilities); err != nil {
+				return fmt.Errorf("subdomain %s of domain %s: %w with provider %s", sd, domain.DomainName, err, providerName)
+			}
+		}
+
+		proxied = proxied || provider.Capabilities.Proxied
+	}
+
+	// proxied is a global setting, it only applies to the providers supporting it
+	if config.Proxied && len(config.Domains) > 0 && !proxied {
+		return errors.New("proxied is not supported by the providers of the domains")
+	}
+
+	return nil
+}
+
+// checkRecordOptions rejects the record options which the provider doesn't support.
+func checkRecordOptions(options *settings.RecordOptions, capabilities registry.Capabilities) error {
+	switch {
+	case options.TTL < 0:
+		return errors.New("ttl should not be negative")
+	case options.TTL > 0 && !capabilities.TTL:
+		return errors.New("ttl is not supported")
+	case options.Proxied != nil && *options.Proxied && !capabilities.Proxied:
+		return errors.New("proxied is not supported")
+	case options.Comment != "" && !capabilities.Comment:
+		return errors.New("comment is not supported")
+	case options.Adopt && !capabilities.Ownership && !capabilities.Zone:
+		return errors.New("adopt is not supported")
+	}
+	return nil
 }
 
 // checkMultiProviderSettings validates multi-provider configuration.
@@ -34,7 +117,7 @@ func checkMultiProviderSettings(config *settings.Settings) error {
 
 	// Validate each provider configuration
 	for providerName, providerConfig := range config.Providers {
-		if err := checkProviderCredentials(providerName, providerConfig); err != nil {
+		if err := checkProviderCredentials(config.GetProviderType(providerName), providerConfig); err != nil {
 			return fmt.Errorf("provider '%s': %w", providerName, err)
 		}
 	}
@@ -79,117 +162,14 @@ func (p *providerConfigAccessor) GetConsumerKey() string { return p.config.Consu
 
 // validateProviderCredentials validates provider credentials using the common interface.
 func validateProviderCredentials(providerName string, accessor credentialAccessor) error {
-	switch providerName {
-	case DNSPOD:
-		if accessor.GetPassword() == "" && accessor.GetLoginToken() == "" {
-			return errors.New("password or login token cannot be empty")
-		}
-	case HE:
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case CLOUDFLARE:
-		if accessor.GetLoginToken() == "" {
-			if accessor.GetEmail() == "" {
-				return errors.New("email cannot be empty")
-			}
-			if accessor.GetPassword() == "" {
-				return errors.New("password cannot be empty")
-			}
-		}
-	case ALIDNS:
-		if accessor.GetEmail() == "" {
-			return errors.New("email cannot be empty")
-		}
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case DIGITALOCEAN:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case DUCK:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case DYNU:
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case DYNV6:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case GOOGLE:
-		fallthrough
-	case NOIP:
-		if accessor.GetEmail() == "" {
-			return errors.New("email cannot be empty")
-		}
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case DREAMHOST:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case SCALEWAY:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case LINODE:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case STRATO:
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case LOOPIASE:
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case INFOMANIAK:
-		if accessor.GetPassword() == "" {
-			return errors.New("password cannot be empty")
-		}
-	case HETZNER:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case IONOS:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case OVH:
-		if accessor.GetAppKey() == "" {
-			return errors.New("app key cannot be empty")
-		}
-		if accessor.GetAppSecret() == "" {
-			return errors.New("app secret cannot be empty")
-		}
-		if accessor.GetConsumerKey() == "" {
-			return errors.New("consumer key cannot be empty")
-		}
-	case TRANSIP:
-		if accessor.GetEmail() == "" {
-			return errors.New("email cannot be empty")
-		}
-		if accessor.GetLoginToken() == "" {
-			return errors.New("login token cannot be empty")
-		}
-	case PORKBUN:
-		if accessor.GetLoginToken() == "" {
-			return errors.New("API key cannot be empty")
-		}
-		if accessor.GetPassword() == "" {
-			return errors.New("secret key cannot be empty")
-		}
-	default:
-		return fmt.Errorf("'%s' is not a supported DNS provider", providerName)
-	}
-
-	return nil
+	return registry.ValidateCredentials(providerName, registry.Credentials{
+		Email:       accessor.GetEmail(),
+		Password:    accessor.GetPassword(),
+		LoginToken:  accessor.GetLoginToken(),
+		AppKey:      accessor.GetAppKey(),
+		AppSecret:   accessor.GetAppSecret(),
+		ConsumerKey: accessor.GetConsumerKey(),
+	})
 }
 
 // checkSingleProviderCredentials validates credentials for legacy single provider mode.
@@ -215,11 +195,15 @@ func checkDomainsWithProviders(config *settings.Settings) error {
 
 		// Validate subdomains
 		for _, sd := range d.SubDomains {
-			if sd == "" {
-				return errors.New("subdomain should not be empty")
+			if err := checkSubDomain(sd); err != nil {
+				return err
 			}
 		}
 
+		if err := checkDomainIPTypes(&d); err != nil {
+			return err
+		}
+
 		// Get the provider for this domain (either domain-specific or global fallback)
 		providerName := config.GetDomainProvider(&d)
 		if providerName == "" {
@@ -253,11 +237,149 @@ func checkDomains(config *settings.Settings) error {
 		}
 
 		for _, sd := range d.SubDomains {
-			if sd == "" {
-				return errors.New("subdomain should not be empty")
+			if err := checkSubDomain(sd); err != nil {
+				return err
+			}
+		}
+
+		if err := checkDomainIPTypes(&d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSubDomain validates a subdomain: "@" for the domain itself, or labels
+// separated by dots, e.g. "vpn.home", where "*" can only be the leftmost label.
+func checkSubDomain(sd string) error {
+	if sd == "" {
+		return errors.New("subdomain should not be empty")
+	}
+	if sd == RootDomain {
+		return nil
+	}
+
+	for i, label := range strings.Split(sd, ".") {
+		switch {
+		case label == "":
+			return fmt.Errorf("subdomain '%s' has an empty label", sd)
+		case label == "*" && i > 0:
+			return fmt.Errorf("subdomain '%s' has a wildcard which is not the leftmost label", sd)
+		case label != "*" && strings.ContainsAny(label, "*@/ \t"):
+			return fmt.Errorf("subdomain '%s' has an invalid label '%s'", sd, label)
+		}
+	}
+
+	return nil
+}
+
+// isWildcard returns true if the subdomain is a wildcard, e.g. "*" or "*.home".
+func isWildcard(sd string) bool {
+	return sd == "*" || strings.HasPrefix(sd, "*.")
+}
+
+// checkScheduler validates the scheduler settings and the per-domain schedules.
+func checkScheduler(config *settings.Settings) error {
+	if config.Scheduler.Concurrency < 0 || config.Scheduler.StartupJitter < 0 {
+		return errors.New("scheduler concurrency and startup_jitter should not be negative")
+	}
+
+	// the limits are matched case-insensitively against the provider names
+	providers := map[string]bool{strings.ToLower(config.Provider): config.Provider != ""}
+	for name := range config.Providers {
+		providers[strings.ToLower(name)] = true
+	}
+	for _, domain := range config.Domains {
+		if domain.Provider != "" {
+			providers[strings.ToLower(domain.Provider)] = true
+		}
+	}
+
+	for name, limit := range config.Scheduler.ProviderConcurrency {
+		if limit < 0 {
+			return fmt.Errorf("scheduler provider_concurrency of %s should not be negative", name)
+		}
+		if !providers[strings.ToLower(name)] {
+			return fmt.Errorf("scheduler provider_concurrency of %s: no such provider is configured", name)
+		}
+	}
+
+	for _, domain := range config.Domains {
+		if domain.Interval < 0 {
+			return fmt.Errorf("interval of domain %s should not be negative", domain.DomainName)
+		}
+
+		if domain.Schedule != "" {
+			if _, err := scheduler.ParseCron(domain.Schedule); err != nil {
+				return fmt.Errorf("schedule of domain %s: %w", domain.DomainName, err)
 			}
 		}
 	}
 
 	return nil
 }
+
+// checkForceUpdateIntervals validates the global and the per-domain force update intervals.
+func checkForceUpdateIntervals(config *settings.Settings) error {
+	if config.ForceUpdateInterval < 0 {
+		return errors.New("force_update_interval should not be negative")
+	}
+
+	for _, domain := range config.Domains {
+		if domain.ForceUpdateInterval < settings.ForceUpdateDisabled {
+			return fmt.Errorf("force_update_interval of domain %s should be positive, or -1 to disable it", domain.DomainName)
+		}
+	}
+
+	return nil
+}
+
+// checkRetry validates the retry policy.
+func checkRetry(retry *settings.Retry) error {
+	if retry.MaxAttempts < 0 {
+		return errors.New("retry max_attempts should not be negative")
+	}
+
+	if retry.BaseDelay < 0 || retry.MaxDelay < 0 {
+		return errors.New("retry delays should not be negative")
+	}
+
+	if retry.Timeout < 0 {
+		return errors.New("retry timeout should not be negative")
+	}
+
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		return errors.New("retry jitter should be between 0 and 1")
+	}
+
+	return nil
+}
+
+// checkDomainIPTypes validates the ip_type overrides of a domain and its subdomains.
+func checkDomainIPTypes(d *settings.Domain) error {
+	if !isValidIPType(d.IPType) {
+		return fmt.Errorf("domain '%s' has an invalid ip_type '%s'", d.DomainName, d.IPType)
+	}
+
+	for sd, options := range d.SubDomainOptions {
+		if !slices.Contains(d.SubDomains, sd) {
+			return fmt.Errorf("domain '%s' has options for subdomain '%s' which is not in sub_domains", d.DomainName, sd)
+		}
+
+		if !isValidIPType(options.IPType) {
+			return fmt.Errorf("subdomain '%s' of domain '%s' has an invalid ip_type '%s'", sd, d.DomainName, options.IPType)
+		}
+	}
+
+	return nil
+}
+
+func isValidIPType(ipType string) bool {
+	switch strings.ToUpper(ipType) {
+	case "", IPV4, IPV6, DUAL:
+		return true
+	default:
+		return false
+	}
+}
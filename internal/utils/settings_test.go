@@ -3,6 +3,7 @@ package utils
 import (
 	"testing"
 
+	"github.com/TimothyYe/godns/internal/provider/registry"
 	"github.com/TimothyYe/godns/internal/settings"
 )
 
@@ -138,6 +139,31 @@ func TestCheckSettings(t *testing.T) {
 			t.Error("multi-provider with invalid credentials should fail")
 		}
 
+		// Named providers of the same type are validated against their type
+		settingNamed := &settings.Settings{
+			Providers: map[string]*settings.ProviderConfig{
+				"cf-home": {Type: "Cloudflare", LoginToken: "home-token"},
+				"cf-work": {Type: "Cloudflare", LoginToken: "work-token"},
+			},
+			Domains: []settings.Domain{
+				{DomainName: "example.com", SubDomains: []string{"www"}, Provider: "cf-home"},
+				{DomainName: "example.org", SubDomains: []string{"www"}, Provider: "cf-work"},
+			},
+		}
+		if err := CheckSettings(settingNamed); err != nil {
+			t.Errorf("named providers should pass, got error: %v", err)
+		}
+
+		settingNamed.Providers["cf-work"] = &settings.ProviderConfig{Type: "Cloudflare"}
+		if err := CheckSettings(settingNamed); err == nil {
+			t.Error("named provider with missing credentials should fail")
+		}
+
+		settingNamed.Providers["cf-work"] = &settings.ProviderConfig{LoginToken: "work-token"}
+		if err := CheckSettings(settingNamed); err == nil {
+			t.Error("named provider without a type should fail")
+		}
+
 		// Domain references non-existent provider should fail
 		settingMissingProvider := &settings.Settings{
 			Providers: map[string]*settings.ProviderConfig{
@@ -326,3 +352,209 @@ func TestCheckSettings(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckSettingsIPTypeOverrides(t *testing.T) {
+	newSettings := func(domain settings.Domain) *settings.Settings {
+		return &settings.Settings{
+			Provider:   "DNSPod",
+			LoginToken: "test-token",
+			Domains:    []settings.Domain{domain},
+		}
+	}
+
+	valid := newSettings(settings.Domain{
+		DomainName: "example.com",
+		SubDomains: []string{"www", "nas"},
+		IPType:     "IPv6",
+		SubDomainOptions: map[string]settings.SubDomainOptions{
+			"www": {IPType: "dual"},
+		},
+	})
+	if err := CheckSettings(valid); err != nil {
+		t.Errorf("valid ip_type overrides should pass, got error: %v", err)
+	}
+
+	invalidDomain := newSettings(settings.Domain{
+		DomainName: "example.com",
+		SubDomains: []string{"www"},
+		IPType:     "IPv5",
+	})
+	if err := CheckSettings(invalidDomain); err == nil {
+		t.Error("invalid domain ip_type should fail")
+	}
+
+	unknownSubDomain := newSettings(settings.Domain{
+		DomainName: "example.com",
+		SubDomains: []string{"www"},
+		SubDomainOptions: map[string]settings.SubDomainOptions{
+			"nas": {IPType: "IPv6"},
+		},
+	})
+	if err := CheckSettings(unknownSubDomain); err == nil {
+		t.Error("options for an unknown subdomain should fail")
+	}
+}
+
+func TestGetDomainIPTypes(t *testing.T) {
+	conf := &settings.Settings{
+		IPType: "IPv4",
+		Domains: []settings.Domain{
+			{DomainName: "a.com", SubDomains: []string{"www"}},
+			{
+				DomainName: "b.com",
+				SubDomains: []string{"www", "nas"},
+				IPType:     "IPv6",
+				SubDomainOptions: map[string]settings.SubDomainOptions{
+					"www": {IPType: "IPv6"},
+				},
+			},
+		},
+	}
+
+	if got := GetDomainIPTypes(conf, &conf.Domains[0]); len(got) != 1 || got[0] != IPV4 {
+		t.Errorf("expected [IPV4] for a.com, got %v", got)
+	}
+
+	if got := GetDomainIPTypes(conf, &conf.Domains[1]); len(got) != 1 || got[0] != IPV6 {
+		t.Errorf("expected [IPV6] for b.com, got %v", got)
+	}
+
+	if got := GetConfiguredIPTypes(conf); len(got) != 2 || got[0] != IPV4 || got[1] != IPV6 {
+		t.Errorf("expected [IPV4 IPV6], got %v", got)
+	}
+}
+
+func TestCheckSettingsCapabilities(t *testing.T) {
+	config := &settings.Settings{
+		Provider:   "DNSPod",
+		LoginToken: "test-token",
+		Proxied:    true,
+		Domains:    []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}}},
+	}
+	if err := CheckSettings(config); err == nil {
+		t.Error("proxied should fail with a provider which doesn't support it")
+	}
+
+	// proxied only applies to the providers supporting it
+	config.Providers = map[string]*settings.ProviderConfig{"Cloudflare": {LoginToken: "test-token"}}
+	config.Domains = append(config.Domains, settings.Domain{DomainName: "example.org", SubDomains: []string{"www"}, Provider: "Cloudflare"})
+	if err := CheckSettings(config); err != nil {
+		t.Errorf("proxied should pass with a provider supporting it, got error: %v", err)
+	}
+
+	if _, exists := registry.Get("IPv4Only"); !exists {
+		registry.Register(registry.Provider{Name: "IPv4Only", New: func() registry.Instance { return nil }})
+	}
+
+	config = &settings.Settings{
+		Provider: "IPv4Only",
+		Domains:  []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}, IPType: "dual"}},
+	}
+	if err := checkCapabilities(config); err == nil {
+		t.Error("AAAA records should fail with a provider which doesn't support IPv6")
+	}
+}
+
+func TestCheckSettingsProviderConcurrency(t *testing.T) {
+	config := &settings.Settings{
+		Provider:   "Cloudflare",
+		LoginToken: "test-token",
+		Domains:    []settings.Domain{{DomainName: "example.com", SubDomains: []string{"www"}}},
+		Scheduler:  settings.Scheduler{ProviderConcurrency: map[string]int{"cloudflare": 2}},
+	}
+	if err := CheckSettings(config); err != nil {
+		t.Errorf("provider_concurrency should match the provider case-insensitively, got error: %v", err)
+	}
+
+	config.Scheduler.ProviderConcurrency = map[string]int{"dnspod": 2}
+	if err := CheckSettings(config); err == nil {
+		t.Error("provider_concurrency should fail for a provider which isn't configured")
+	}
+}
+
+func TestCheckSettingsForceUpdateInterval(t *testing.T) {
+	config := &settings.Settings{
+		Provider:            "Cloudflare",
+		LoginToken:          "test-token",
+		ForceUpdateInterval: 3600,
+		Domains: []settings.Domain{{
+			DomainName:          "example.com",
+			SubDomains:          []string{"www"},
+			ForceUpdateInterval: settings.ForceUpdateDisabled,
+		}},
+	}
+	if err := CheckSettings(config); err != nil {
+		t.Errorf("a domain should be able to disable the force update, got error: %v", err)
+	}
+	if interval := config.GetDomainForceUpdateInterval(&config.Domains[0]); interval != 0 {
+		t.Errorf("expected the force update to be disabled for the domain, got %d", interval)
+	}
+
+	config.Domains[0].ForceUpdateInterval = -2
+	if err := CheckSettings(config); err == nil {
+		t.Error("force_update_interval should fail below -1")
+	}
+}
+
+func TestCheckSettingsRecordOptions(t *testing.T) {
+	proxied := true
+	config := &settings.Settings{
+		Provider:   "Cloudflare",
+		LoginToken: "test-token",
+		Domains: []settings.Domain{{
+			DomainName:    "example.com",
+			SubDomains:    []string{"www"},
+			RecordOptions: settings.RecordOptions{TTL: 300, Proxied: &proxied, Comment: "GoDNS"},
+		}},
+	}
+	if err := CheckSettings(config); err != nil {
+		t.Errorf("record options supported by the provider should pass, got error: %v", err)
+	}
+
+	config.Domains[0].TTL = -1
+	if err := CheckSettings(config); err == nil {
+		t.Error("negative ttl should fail")
+	}
+
+	config = &settings.Settings{
+		Provider:   "DuckDNS",
+		LoginToken: "test-token",
+		Domains: []settings.Domain{{
+			DomainName: "example.com",
+			SubDomains: []string{"www"},
+			SubDomainOptions: map[string]settings.SubDomainOptions{
+				"www": {RecordOptions: settings.RecordOptions{TTL: 60}},
+			},
+		}},
+	}
+	if err := CheckSettings(config); err == nil {
+		t.Error("ttl should fail with a provider which doesn't support it")
+	}
+}
+
+func TestCheckSettingsSubDomains(t *testing.T) {
+	for _, sd := range []string{"@", "www", "vpn.home", "*", "*.home", "_acme.home"} {
+		if err := checkSubDomain(sd); err != nil {
+			t.Errorf("subdomain %q should pass, got error: %v", sd, err)
+		}
+	}
+	for _, sd := range []string{"", "home.", "a..b", "home.*", "*home", "a b", "@.home"} {
+		if err := checkSubDomain(sd); err == nil {
+			t.Errorf("subdomain %q should fail", sd)
+		}
+	}
+
+	config := &settings.Settings{
+		Provider:   "Cloudflare",
+		LoginToken: "test-token",
+		Domains:    []settings.Domain{{DomainName: "example.com", SubDomains: []string{"*.home", "vpn.home"}}},
+	}
+	if err := CheckSettings(config); err != nil {
+		t.Errorf("wildcard subdomains should pass with a provider supporting them, got error: %v", err)
+	}
+
+	config.Provider = "DuckDNS"
+	if err := CheckSettings(config); err == nil {
+		t.Error("wildcard subdomains should fail with a provider which doesn't support them")
+	}
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/TimothyYe/godns/internal/settings"
+	dnsResolver "github.com/TimothyYe/godns/pkg/resolver"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/publicsuffix"
+)
+
+// ZoneLookup returns the name of the zone holding a hostname.
+type ZoneLookup func(hostname string) (string, error)
+
+// NewZoneLookup returns a ZoneLookup querying the SOA records through the given
+// DNS server, or through the system DNS servers if it is empty. It returns nil
+// if there is no DNS server to query, e.g. on Windows without resolver, so that
+// the registrable domains are used.
+func NewZoneLookup(resolver string) ZoneLookup {
+	if resolver != "" {
+		return dnsResolver.New([]string{resolver}).LookupZone
+	}
+
+	res, err := dnsResolver.NewFromResolvConf("/etc/resolv.conf")
+	if err != nil {
+		log.Debugf("No DNS server to look up the zones, using the registrable domains: %s", err)
+		return nil
+	}
+	return res.LookupZone
+}
+
+// SplitHostname splits a hostname into the zone holding it and its subdomain,
+// e.g. vpn.home.example.co.uk into example.co.uk and vpn.home, or into
+// home.example.co.uk and vpn if it is a delegated zone. The SOA records are
+// looked up from the hostname up to its registrable domain, which is used if
+// no zone is found.
+func SplitHostname(hostname string, lookup ZoneLookup) (string, string, error) {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if err := checkSubDomain(hostname); err != nil || hostname == RootDomain {
+		return "", "", fmt.Errorf("invalid hostname '%s'", hostname)
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimPrefix(hostname, "*."))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid hostname '%s': %w", hostname, err)
+	}
+
+	zone := registrable
+	if lookup != nil {
+		if found, ok := lookupZone(hostname, registrable, lookup); ok {
+			zone = found
+		} else {
+			log.Warnf("Failed to look up the zone of %s, using %s", hostname, registrable)
+		}
+	}
+
+	if zone == hostname {
+		return zone, RootDomain, nil
+	}
+	return zone, strings.TrimSuffix(hostname, "."+zone), nil
+}
+
+// lookupZone walks up from the hostname to its registrable domain, until the zone holding it is found.
+func lookupZone(hostname, registrable string, lookup ZoneLookup) (string, bool) {
+	for name := hostname; ; {
+		zone, err := lookup(name)
+		zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+		switch {
+		case err != nil:
+			log.Debugf("Failed to look up the zone of %s: %s", name, err)
+		case isZoneOf(zone, hostname):
+			return zone, true
+		default:
+			// e.g. the zone of the target of a CNAME record
+			log.Debugf("Ignoring the zone %s returned for %s", zone, name)
+		}
+
+		if name == registrable {
+			return "", false
+		}
+		_, name, _ = strings.Cut(name, ".")
+	}
+}
+
+// isZoneOf returns true if the zone can hold the hostname: the hostname is in
+// the zone, which is not a public suffix such as com or co.uk.
+func isZoneOf(zone, hostname string) bool {
+	if zone != hostname && !strings.HasSuffix(hostname, "."+zone) {
+		return false
+	}
+	suffix, icann := publicsuffix.PublicSuffix(zone)
+	return suffix != zone || !icann
+}
+
+// ExpandHostnames replaces the domains listing hostnames by one domain per zone
+// holding them, with the same settings.
+func ExpandHostnames(config *settings.Settings, lookup ZoneLookup) error {
+	keys := map[string]bool{}
+	for i := range config.Domains {
+		if len(config.Domains[i].Hostnames) == 0 {
+			keys[config.DomainKey(&config.Domains[i])] = true
+		}
+	}
+
+	var domains []settings.Domain
+	for _, domain := range config.Domains {
+		if len(domain.Hostnames) == 0 {
+			domains = append(domains, domain)
+			continue
+		}
+		if domain.DomainName != "" || len(domain.SubDomains) > 0 {
+			return errors.New("a domain should set either hostnames, or domain_name and sub_domains")
+		}
+		for hostname := range domain.SubDomainOptions {
+			if !slices.Contains(domain.Hostnames, hostname) {
+				return fmt.Errorf("options for hostname '%s' which is not in hostnames", hostname)
+			}
+		}
+
+		// the zones are kept in the order of the hostnames
+		expanded := map[string]*settings.Domain{}
+		var zones []string
+		for _, hostname := range domain.Hostnames {
+			zone, subDomain, err := SplitHostname(hostname, lookup)
+			if err != nil {
+				return err
+			}
+			log.Debugf("Hostname %s is %s in zone %s", hostname, subDomain, zone)
+
+			zoneDomain, exists := expanded[zone]
+			if !exists {
+				zoneDomain = new(settings.Domain)
+				*zoneDomain = domain
+				zoneDomain.DomainName = zone
+				zoneDomain.Hostnames = nil
+				zoneDomain.SubDomainOptions = nil
+				expanded[zone] = zoneDomain
+				zones = append(zones, zone)
+			}
+			zoneDomain.SubDomains = append(zoneDomain.SubDomains, subDomain)
+
+			// the subdomain options are keyed by hostname
+			if options, exists := domain.SubDomainOptions[hostname]; exists {
+				if zoneDomain.SubDomainOptions == nil {
+					zoneDomain.SubDomainOptions = map[string]settings.SubDomainOptions{}
+				}
+				zoneDomain.SubDomainOptions[subDomain] = options
+			}
+		}
+
+		for _, zone := range zones {
+			zoneDomain := expanded[zone]
+			key := config.DomainKey(zoneDomain)
+			if keys[key] {
+				return fmt.Errorf("the hostnames of zone %s are already configured by another domain", zone)
+			}
+			keys[key] = true
+			domains = append(domains, *zoneDomain)
+		}
+	}
+
+	config.SetExpandedDomains(domains)
+	return nil
+}
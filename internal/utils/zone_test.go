@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/TimothyYe/godns/internal/settings"
+)
+
+// fakeZones answers the SOA lookups like a resolver knowing the given zones,
+// and the CNAME records mapping names to the zone of their target.
+func fakeZones(zones []string, cnames map[string]string) ZoneLookup {
+	return func(hostname string) (string, error) {
+		if zone, exists := cnames[hostname]; exists {
+			return zone + ".", nil
+		}
+		for name := hostname; name != ""; {
+			if slices.Contains(zones, name) {
+				return name + ".", nil
+			}
+			_, name, _ = strings.Cut(name, ".")
+		}
+		return "", errors.New("NXDOMAIN")
+	}
+}
+
+func TestSplitHostname(t *testing.T) {
+	lookup := fakeZones(
+		[]string{"com", "uk", "co.uk", "example.com", "home.example.com", "example.co.uk"},
+		map[string]string{"cdn.example.com": "cdn-provider.net"},
+	)
+
+	tests := []struct {
+		hostname  string
+		zone      string
+		subDomain string
+	}{
+		{"www.example.com", "example.com", "www"},
+		{"Example.com.", "example.com", "@"},
+		{"vpn.home.example.com", "home.example.com", "vpn"},
+		{"home.example.com", "home.example.com", "@"},
+		{"*.home.example.com", "home.example.com", "*"},
+		{"a.b.example.co.uk", "example.co.uk", "a.b"},
+		// the zone of the target of a CNAME record is ignored
+		{"cdn.example.com", "example.com", "cdn"},
+		// a missing domain is only in a public suffix zone, its registrable domain is used
+		{"www.missing.co.uk", "missing.co.uk", "www"},
+	}
+	for _, tt := range tests {
+		zone, subDomain, err := SplitHostname(tt.hostname, lookup)
+		if err != nil || zone != tt.zone || subDomain != tt.subDomain {
+			t.Errorf("SplitHostname(%s): expected %s in %s, got %s in %s (%v)", tt.hostname, tt.subDomain, tt.zone, subDomain, zone, err)
+		}
+	}
+
+	// without any lookup, the public suffix list is used
+	if zone, subDomain, err := SplitHostname("vpn.home.example.co.uk", nil); err != nil || zone != "example.co.uk" || subDomain != "vpn.home" {
+		t.Errorf("expected vpn.home in example.co.uk, got %s in %s (%v)", subDomain, zone, err)
+	}
+
+	for _, hostname := range []string{"", "@", "co.uk", "a..example.com"} {
+		if _, _, err := SplitHostname(hostname, nil); err == nil {
+			t.Errorf("SplitHostname(%q) should fail", hostname)
+		}
+	}
+}
+
+func TestExpandHostnames(t *testing.T) {
+	config := &settings.Settings{
+		Provider: "Cloudflare",
+		Domains: []settings.Domain{
+			{DomainName: "example.org", SubDomains: []string{"www"}},
+			{
+				Hostnames: []string{"www.example.com", "vpn.home.example.com", "example.com"},
+				IPType:    "IPv6",
+				SubDomainOptions: map[string]settings.SubDomainOptions{
+					"vpn.home.example.com": {IPType: "IPv4"},
+				},
+			},
+		},
+	}
+
+	lookup := fakeZones([]string{"example.com", "home.example.com", "example.org"}, nil)
+	if err := ExpandHostnames(config, lookup); err != nil {
+		t.Fatalf("ExpandHostnames failed: %v", err)
+	}
+
+	if len(config.Domains) != 3 {
+		t.Fatalf("expected 3 domains, got %+v", config.Domains)
+	}
+	if domain := config.Domains[1]; domain.DomainName != "example.com" || !slices.Equal(domain.SubDomains, []string{"www", "@"}) ||
+		domain.IPType != "IPv6" || len(domain.Hostnames) != 0 {
+		t.Errorf("unexpected domain: %+v", domain)
+	}
+	if domain := config.Domains[2]; domain.DomainName != "home.example.com" || !slices.Equal(domain.SubDomains, []string{"vpn"}) ||
+		domain.SubDomainOptions["vpn"].IPType != "IPv4" {
+		t.Errorf("unexpected domain: %+v", domain)
+	}
+
+	// the hostnames are saved as configured, not split by zone
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := config.Clone().SaveSettings(path); err != nil {
+		t.Fatal(err)
+	}
+	var saved settings.Settings
+	if err := settings.LoadSettings(path, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Domains) != 2 || len(saved.Domains[1].Hostnames) != 3 || saved.Domains[1].DomainName != "" {
+		t.Errorf("expected the configured domains to be saved, got %+v", saved.Domains)
+	}
+
+	// a zone can't be configured twice
+	config.Domains = append(config.Domains, settings.Domain{Hostnames: []string{"api.example.org"}})
+	if err := ExpandHostnames(config, lookup); err == nil {
+		t.Error("expected the zone configured twice to be rejected")
+	}
+
+	config.Domains = []settings.Domain{{DomainName: "example.com", Hostnames: []string{"www.example.com"}}}
+	if err := ExpandHostnames(config, lookup); err == nil {
+		t.Error("expected a domain with both a domain name and hostnames to be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package lib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// netlink multicast groups from linux/rtnetlink.h, not exported by the syscall package
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+// watchAddressChanges subscribes to the netlink link and address events and
+// calls notify with the index of the interface of each event, until stopCh
+// is closed.
+func watchAddressChanges(stopCh <-chan struct{}, notify func(index int)) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return fmt.Errorf("failed to open netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	addr := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		return fmt.Errorf("failed to bind netlink socket: %w", err)
+	}
+
+	// wake up regularly to check stopCh
+	timeout := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		return fmt.Errorf("failed to set netlink socket timeout: %w", err)
+	}
+
+	buf := make([]byte, 1<<16)
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		default:
+		}
+
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			switch {
+			case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+				continue
+			case errors.Is(err, syscall.ENOBUFS):
+				// some events were dropped, report a change of any interface
+				notify(0)
+				continue
+			}
+			return fmt.Errorf("failed to read netlink socket: %w", err)
+		}
+
+		indexes, err := parseAddressEvents(buf[:n])
+		if err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			notify(index)
+		}
+	}
+}
+
+// parseAddressEvents returns the interface indexes of the link and address
+// events of a netlink datagram.
+func parseAddressEvents(data []byte) ([]int, error) {
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse netlink message: %w", err)
+	}
+
+	var indexes []int
+	for _, msg := range msgs {
+		switch msg.Header.Type {
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			if len(msg.Data) >= syscall.SizeofIfAddrmsg {
+				indexes = append(indexes, int(binary.NativeEndian.Uint32(msg.Data[4:8])))
+			}
+		case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+			if len(msg.Data) >= syscall.SizeofIfInfomsg {
+				indexes = append(indexes, int(int32(binary.NativeEndian.Uint32(msg.Data[4:8]))))
+			}
+		}
+	}
+
+	return indexes, nil
+}
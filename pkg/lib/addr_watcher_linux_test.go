@@ -0,0 +1,34 @@
+//go:build linux
+
+package lib
+
+import (
+	"encoding/binary"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+func netlinkMessage(msgType uint16, index uint32, size int) []byte {
+	msg := make([]byte, syscall.NLMSG_HDRLEN+size)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	binary.NativeEndian.PutUint32(msg[syscall.NLMSG_HDRLEN+4:syscall.NLMSG_HDRLEN+8], index)
+	return msg
+}
+
+func TestParseAddressEvents(t *testing.T) {
+	var data []byte
+	data = append(data, netlinkMessage(syscall.RTM_NEWADDR, 3, syscall.SizeofIfAddrmsg)...)
+	data = append(data, netlinkMessage(syscall.RTM_NEWROUTE, 4, syscall.SizeofRtMsg)...)
+	data = append(data, netlinkMessage(syscall.RTM_DELLINK, 5, syscall.SizeofIfInfomsg)...)
+
+	indexes, err := parseAddressEvents(data)
+	if err != nil {
+		t.Fatalf("failed to parse the events: %s", err)
+	}
+
+	if !slices.Equal(indexes, []int{3, 5}) {
+		t.Errorf("expected the indexes [3 5], got %v", indexes)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package lib
+
+import "errors"
+
+// watchAddressChanges is only implemented on Linux, other platforms rely on polling.
+func watchAddressChanges(_ <-chan struct{}, _ func(index int)) error {
+	return errors.New("address change events are only supported on Linux")
+}
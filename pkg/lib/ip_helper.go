@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -26,15 +27,26 @@ import (
 )
 
 type IPHelper struct {
-	reqURLs       []string
-	currentIP     string
+	reqURLs       map[string][]string
+	currentIPs    map[string]string
+	ipTypes       []string
 	mutex         sync.RWMutex
 	configuration *settings.Settings
 	idx           int64
 	stopCh        chan struct{}
 	stopOnce      sync.Once
+	changes       chan struct{}
+	// intervals receives the new refresh interval when the configuration changes
+	intervals chan time.Duration
+	// watchedInterface is the interface watched for address changes, empty if none
+	watchedInterface string
+	// watchStop stops the watcher of watchedInterface
+	watchStop chan struct{}
 }
 
+// addressSettleDelay is the delay without any address change before the IP is refreshed.
+const addressSettleDelay = 2 * time.Second
+
 var (
 	helperInstance *IPHelper
 	helperOnce     sync.Once
@@ -45,31 +57,41 @@ func (helper *IPHelper) UpdateConfiguration(conf *settings.Settings) {
 	defer helper.mutex.Unlock()
 
 	// clear urls
-	helper.reqURLs = helper.reqURLs[:0]
+	helper.reqURLs = map[string][]string{}
 	// reset the index
 	helper.idx = -1
-
-	if conf.IPType == "" || strings.ToUpper(conf.IPType) == utils.IPV4 {
-		// filter empty urls
-		for _, url := range conf.IPUrls {
-			if url != "" {
-				helper.reqURLs = append(helper.reqURLs, url)
-			}
+	helper.ipTypes = utils.GetConfiguredIPTypes(conf)
+	helper.configuration = conf
+	helper.watch(conf.IPInterface)
+
+	// replace a pending interval which the refresh goroutine didn't receive yet
+	if interval := refreshInterval(conf); interval > 0 {
+		select {
+		case <-helper.intervals:
+		default:
 		}
+		helper.intervals <- interval
+	}
 
-		if conf.IPUrl != "" {
-			helper.reqURLs = append(helper.reqURLs, conf.IPUrl)
+	for _, ipType := range helper.ipTypes {
+		var urls []string
+		var singleURL string
+
+		if ipType == utils.IPV4 {
+			urls, singleURL = conf.IPUrls, conf.IPUrl
+		} else {
+			urls, singleURL = conf.IPV6Urls, conf.IPV6Url
 		}
-	} else {
+
 		// filter empty urls
-		for _, url := range conf.IPV6Urls {
+		for _, url := range urls {
 			if url != "" {
-				helper.reqURLs = append(helper.reqURLs, url)
+				helper.reqURLs[ipType] = append(helper.reqURLs[ipType], url)
 			}
 		}
 
-		if conf.IPV6Url != "" {
-			helper.reqURLs = append(helper.reqURLs, conf.IPV6Url)
+		if singleURL != "" {
+			helper.reqURLs[ipType] = append(helper.reqURLs[ipType], singleURL)
 		}
 	}
 
@@ -79,13 +101,19 @@ func (helper *IPHelper) UpdateConfiguration(conf *settings.Settings) {
 func GetIPHelperInstance(conf *settings.Settings) *IPHelper {
 	helperOnce.Do(func() {
 		helperInstance = &IPHelper{
+			reqURLs:       map[string][]string{},
+			currentIPs:    map[string]string{},
+			ipTypes:       utils.GetConfiguredIPTypes(conf),
 			configuration: conf,
 			idx:           -1,
 			stopCh:        make(chan struct{}),
+			changes:       make(chan struct{}, 1),
+			intervals:     make(chan time.Duration, 1),
 		}
 
 		SafeGo(func() {
-			ticker := time.NewTicker(time.Second * time.Duration(conf.Interval))
+			interval := refreshInterval(conf)
+			ticker := time.NewTicker(interval)
 			defer ticker.Stop()
 
 			helperInstance.getCurrentIP()
@@ -93,68 +121,226 @@ func GetIPHelperInstance(conf *settings.Settings) *IPHelper {
 				select {
 				case <-helperInstance.stopCh:
 					return
+				case newInterval := <-helperInstance.intervals:
+					if newInterval != interval {
+						log.Debugf("Refreshing the current IP every %s", newInterval)
+						interval = newInterval
+						ticker.Reset(interval)
+					}
 				case <-ticker.C:
 					helperInstance.getCurrentIP()
 				}
 			}
 		})
+
+		helperInstance.mutex.Lock()
+		helperInstance.watch(conf.IPInterface)
+		helperInstance.mutex.Unlock()
 	})
 
 	return helperInstance
 }
 
+// refreshInterval returns the interval of the periodic refresh of the IP: at
+// least as often as the most frequently updated domain.
+func refreshInterval(conf *settings.Settings) time.Duration {
+	return time.Second * time.Duration(conf.GetMinInterval())
+}
+
 // Stop signals the background IP-refresh goroutine to exit. Safe to call
 // multiple times. Intended for process shutdown — the helper is a singleton
 // and will not auto-restart after Stop.
 func (helper *IPHelper) Stop() {
 	helper.stopOnce.Do(func() {
 		close(helper.stopCh)
+
+		helper.mutex.Lock()
+		defer helper.mutex.Unlock()
+		helper.watch("")
 	})
 }
 
-func (helper *IPHelper) GetCurrentIP() string {
-	// for the first load
-	if helper.currentIP == "" {
-		helper.getCurrentIP()
+// watch starts watching the address changes of the given network interface,
+// stopping the watcher of the previous one. An empty name stops watching.
+// The caller must hold the write lock.
+func (helper *IPHelper) watch(ipInterface string) {
+	if ipInterface == helper.watchedInterface {
+		return
+	}
+
+	if helper.watchStop != nil {
+		close(helper.watchStop)
+		helper.watchStop = nil
+	}
+	helper.watchedInterface = ipInterface
+
+	if ipInterface == "" {
+		return
+	}
+
+	// the helper won't restart after Stop
+	select {
+	case <-helper.stopCh:
+		helper.watchedInterface = ""
+		return
+	default:
+	}
+
+	stopCh := make(chan struct{})
+	helper.watchStop = stopCh
+	SafeGo(func() {
+		helper.watchInterface(ipInterface, stopCh)
+	})
+}
+
+// config returns the current configuration of the helper.
+func (helper *IPHelper) config() *settings.Settings {
+	helper.mutex.RLock()
+	defer helper.mutex.RUnlock()
+
+	return helper.configuration
+}
+
+// Changes returns a channel receiving a value when the current IP changed
+// after an address change of the configured network interface.
+func (helper *IPHelper) Changes() <-chan struct{} {
+	return helper.changes
+}
+
+// watchInterface refreshes the IP as soon as the addresses of the given
+// network interface change, on top of the periodic refresh, until stopCh is closed.
+func (helper *IPHelper) watchInterface(ipInterface string, stopCh <-chan struct{}) {
+	events := make(chan struct{}, 1)
+	SafeGo(func() {
+		err := watchAddressChanges(stopCh, func(index int) {
+			// the index of a removed interface can't be resolved anymore, don't skip it
+			if iface, err := net.InterfaceByIndex(index); err == nil && iface.Name != ipInterface {
+				return
+			}
+
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil {
+			log.Warnf("Failed to watch the address changes of %s, falling back to polling: %s", ipInterface, err)
+		}
+	})
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-events:
+		}
+
+		// a reconnect fires a burst of events, wait for it to settle
+		settle := time.NewTimer(addressSettleDelay)
+	wait:
+		for {
+			select {
+			case <-stopCh:
+				settle.Stop()
+				return
+			case <-events:
+				settle.Reset(addressSettleDelay)
+			case <-settle.C:
+				break wait
+			}
+		}
+
+		log.Debugf("Addresses of %s changed, refreshing the current IP", ipInterface)
+		if helper.refreshAll() {
+			select {
+			case helper.changes <- struct{}{}:
+			default:
+			}
+		}
 	}
+}
+
+// refreshAll refreshes the IP of every configured family and reports whether one of them changed.
+func (helper *IPHelper) refreshAll() bool {
+	helper.mutex.RLock()
+	previousIPs := maps.Clone(helper.currentIPs)
+	helper.mutex.RUnlock()
+
+	helper.getCurrentIP()
 
 	helper.mutex.RLock()
 	defer helper.mutex.RUnlock()
+	return !maps.Equal(previousIPs, helper.currentIPs)
+}
+
+// GetCurrentIP returns the current IP of the first configured IP family.
+// In dual-stack mode use GetCurrentIPByType to get each family explicitly.
+func (helper *IPHelper) GetCurrentIP() string {
+	helper.mutex.RLock()
+	ipTypes := helper.ipTypes
+	helper.mutex.RUnlock()
+
+	if len(ipTypes) == 0 {
+		return ""
+	}
+
+	return helper.GetCurrentIPByType(ipTypes[0])
+}
+
+// GetCurrentIPByType returns the current IP of the given family (IPV4 or IPV6).
+func (helper *IPHelper) GetCurrentIPByType(ipType string) string {
+	helper.mutex.RLock()
+	ip := helper.currentIPs[ipType]
+	helper.mutex.RUnlock()
+
+	// for the first load
+	if ip == "" {
+		helper.refreshIP(ipType)
 
-	return helper.currentIP
+		helper.mutex.RLock()
+		ip = helper.currentIPs[ipType]
+		helper.mutex.RUnlock()
+	}
+
+	return ip
 }
 
-func (helper *IPHelper) setCurrentIP(ip string) {
+func (helper *IPHelper) setCurrentIP(ipType, ip string) {
 	helper.mutex.Lock()
 	defer helper.mutex.Unlock()
 
-	helper.currentIP = ip
+	helper.currentIPs[ipType] = ip
 }
 
-func (helper *IPHelper) getNext() string {
+func (helper *IPHelper) getNext(ipType string) string {
 	newIdx := atomic.AddInt64(&helper.idx, 1)
 
 	helper.mutex.RLock()
 	defer helper.mutex.RUnlock()
-	newIdx %= int64(len(helper.reqURLs))
-	next := helper.reqURLs[newIdx]
+	urls := helper.reqURLs[ipType]
+	if len(urls) == 0 {
+		return ""
+	}
+	newIdx %= int64(len(urls))
+	next := urls[newIdx]
 	return next
 }
 
 func (helper *IPHelper) getIPFromMikrotik() string {
-	u, err := url.Parse(helper.configuration.Mikrotik.Addr)
+	conf := helper.config()
+	u, err := url.Parse(conf.Mikrotik.Addr)
 	if err != nil {
 		log.Error("fail to parse mikrotik address: ", err)
 		return ""
 	}
 	u.Path = path.Join(u.Path, "/rest/ip/address")
 	q := u.Query()
-	q.Add("interface", helper.configuration.Mikrotik.Interface)
+	q.Add("interface", conf.Mikrotik.Interface)
 	q.Add(".proplist", "address")
 	u.RawQuery = q.Encode()
 
 	req, _ := http.NewRequest("GET", u.String(), nil)
-	auth := fmt.Sprintf("%s:%s", helper.configuration.Mikrotik.Username, helper.configuration.Mikrotik.Password)
+	auth := fmt.Sprintf("%s:%s", conf.Mikrotik.Username, conf.Mikrotik.Password)
 	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
 	req.Header.Add("Content-Type", "application/json")
 
@@ -194,17 +380,18 @@ func (helper *IPHelper) getIPFromMikrotik() string {
 	return res[0]
 }
 
-// getIPFromInterface gets IP address from the specific interface.
-func (helper *IPHelper) getIPFromInterface() (string, error) {
-	ifaces, err := net.InterfaceByName(helper.configuration.IPInterface)
+// getIPFromInterface gets IP address of the given family from the specific interface.
+func (helper *IPHelper) getIPFromInterface(ipType string) (string, error) {
+	conf := helper.config()
+	ifaces, err := net.InterfaceByName(conf.IPInterface)
 	if err != nil {
-		log.Error("Can't get network device "+helper.configuration.IPInterface+":", err)
+		log.Error("Can't get network device "+conf.IPInterface+":", err)
 		return "", err
 	}
 
 	addrs, err := ifaces.Addrs()
 	if err != nil {
-		log.Error("Can't get address from "+helper.configuration.IPInterface+":", err)
+		log.Error("Can't get address from "+conf.IPInterface+":", err)
 		return "", err
 	}
 
@@ -220,34 +407,35 @@ func (helper *IPHelper) getIPFromInterface() (string, error) {
 			continue
 		}
 
-		if !helper.configuration.AllowPrivate && ip.IsPrivate() {
+		if !conf.AllowPrivate && ip.IsPrivate() {
 			continue
 		}
 
-		if isIPv4(ip.String()) {
-			if strings.ToUpper(helper.configuration.IPType) != utils.IPV4 {
-				continue
-			}
-		} else {
-			if strings.ToUpper(helper.configuration.IPType) != utils.IPV6 {
-				continue
-			}
+		if utils.GetIPType(ip.String()) != ipType {
+			continue
 		}
 
 		if ip.String() != "" {
-			log.Debugf("Get ip success from network interface by: %s, IP: %s", helper.configuration.IPInterface, ip.String())
+			log.Debugf("Get ip success from network interface by: %s, IP: %s", conf.IPInterface, ip.String())
 			return ip.String(), nil
 		}
 	}
-	return "", errors.New("can't get a valid address from " + helper.configuration.IPInterface)
+	return "", errors.New("can't get a valid address from " + conf.IPInterface)
 }
 
-func isIPv4(ip string) bool {
-	return strings.Count(ip, ":") < 2
+// getCurrentIP refreshes the IP of every configured family.
+func (helper *IPHelper) getCurrentIP() {
+	helper.mutex.RLock()
+	ipTypes := helper.ipTypes
+	helper.mutex.RUnlock()
+
+	for _, ipType := range ipTypes {
+		helper.refreshIP(ipType)
+	}
 }
 
-// getCurrentIP gets an IP from either internet or specific interface, depending on configuration.
-func (helper *IPHelper) getCurrentIP() {
+// refreshIP gets an IP of the given family from either internet or specific interface, depending on configuration.
+func (helper *IPHelper) refreshIP(ipType string) {
 	var err error
 	var ip string
 
@@ -256,49 +444,50 @@ func (helper *IPHelper) getCurrentIP() {
 	// under the read lock and release before any work — sub-calls take
 	// their own locks (getNext uses RLock, setCurrentIP uses Lock).
 	helper.mutex.RLock()
-	hasURLs := len(helper.reqURLs) > 0
+	hasURLs := len(helper.reqURLs[ipType]) > 0
+	conf := helper.configuration
 	helper.mutex.RUnlock()
 
-	if helper.configuration.Mikrotik.Enabled {
+	if conf.Mikrotik.Enabled {
 		ip = helper.getIPFromMikrotik()
-		if ip == "" {
+		if ip == "" || utils.GetIPType(ip) != ipType {
 			log.Error("get ip from mikrotik failed. Fallback to get ip from onlinke if possible.")
 		} else {
-			helper.setCurrentIP(ip)
+			helper.setCurrentIP(ipType, ip)
 			return
 		}
 	}
 
 	if hasURLs {
-		ip = helper.getIPOnline()
+		ip = helper.getIPOnline(ipType)
 		if ip == "" {
 			log.Error("get ip online failed. Fallback to get ip from interface if possible.")
 		} else {
-			helper.setCurrentIP(ip)
+			helper.setCurrentIP(ipType, ip)
 			return
 		}
 	}
 
-	if helper.configuration.IPInterface != "" {
-		ip, err = helper.getIPFromInterface()
+	if conf.IPInterface != "" {
+		ip, err = helper.getIPFromInterface(ipType)
 		if err != nil {
 			log.Error("get ip from interface failed. There is no more ways to try.")
 		} else {
-			helper.setCurrentIP(ip)
+			helper.setCurrentIP(ipType, ip)
 			return
 		}
 	}
 }
 
-// getIPOnline gets public IP from internet.
-func (helper *IPHelper) getIPOnline() string {
+// getIPOnline gets public IP of the given family from internet.
+func (helper *IPHelper) getIPOnline(ipType string) string {
 	transport := &http.Transport{
 		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
-			proto := "tcp"
-
-			if strings.ToUpper(helper.configuration.IPType) == utils.IPV4 {
-				// Force the network to "tcp4" to use only IPv4
-				proto = "tcp4"
+			// Force the network to "tcp4" or "tcp6" so that dual-stack
+			// hosts report the address of the requested family
+			proto := "tcp4"
+			if ipType == utils.IPV6 {
+				proto = "tcp6"
 			}
 
 			return (&net.Dialer{
@@ -318,17 +507,20 @@ func (helper *IPHelper) getIPOnline() string {
 	// Cap attempts so a configuration with all-broken IP URLs doesn't spin
 	// forever. Once exhausted, return "" and let callers fall back to the
 	// interface-based path.
-	maxAttempts := len(helper.reqURLs) * 3
+	helper.mutex.RLock()
+	maxAttempts := len(helper.reqURLs[ipType]) * 3
+	userAgent := helper.configuration.UserAgent
+	helper.mutex.RUnlock()
 	if maxAttempts < 3 {
 		maxAttempts = 3
 	}
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
-		reqURL := helper.getNext()
+		reqURL := helper.getNext(ipType)
 		req, _ := http.NewRequest("GET", reqURL, nil)
 
-		if helper.configuration.UserAgent != "" {
-			req.Header.Set("User-Agent", helper.configuration.UserAgent)
+		if userAgent != "" {
+			req.Header.Set("User-Agent", userAgent)
 		}
 
 		response, err := client.Do(req)
@@ -359,18 +551,11 @@ func (helper *IPHelper) getIPOnline() string {
 			continue
 		}
 
-		if isIPv4(onlineIP) {
-			if strings.ToUpper(helper.configuration.IPType) != utils.IPV4 {
-				log.Warnf("The online IP (%s) from %s is not IPV6, will skip it.", onlineIP, reqURL)
-				response.Body.Close()
-				continue
-			}
-		} else {
-			if strings.ToUpper(helper.configuration.IPType) != utils.IPV6 {
-				log.Warnf("The online IP (%s) from %s is not IPV4, will skip it.", onlineIP, reqURL)
-				response.Body.Close()
-				continue
-			}
+		if utils.GetIPType(onlineIP) != ipType {
+			log.Warnf("The online IP (%s) from %s is not %s, will skip it.", onlineIP, reqURL, ipType)
+			response.Body.Close()
+			onlineIP = ""
+			continue
 		}
 
 		log.Debugf("Get ip success by: %s, online IP: %s", reqURL, onlineIP)
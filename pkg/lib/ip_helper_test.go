@@ -62,3 +62,36 @@ func TestIPHelperStop(t *testing.T) {
 	// Second Stop must be safe — sync.Once guards the close.
 	helper.Stop()
 }
+
+// TestUpdateConfiguration verifies that a new configuration starts or stops
+// the interface watcher and hands the new refresh interval to the ticker.
+func TestUpdateConfiguration(t *testing.T) {
+	helper := &IPHelper{
+		currentIPs: map[string]string{},
+		stopCh:     make(chan struct{}),
+		changes:    make(chan struct{}, 1),
+		intervals:  make(chan time.Duration, 1),
+	}
+	defer helper.Stop()
+
+	helper.UpdateConfiguration(&settings.Settings{Interval: 60, IPInterface: "lo"})
+	if helper.watchedInterface != "lo" || helper.watchStop == nil {
+		t.Fatalf("expected lo to be watched, got %q", helper.watchedInterface)
+	}
+	watchStop := helper.watchStop
+
+	helper.UpdateConfiguration(&settings.Settings{Interval: 30})
+	if helper.watchedInterface != "" || helper.watchStop != nil {
+		t.Errorf("expected the watcher to be stopped, got %q", helper.watchedInterface)
+	}
+	select {
+	case <-watchStop:
+	default:
+		t.Error("expected the previous watcher to be closed")
+	}
+
+	// the pending interval is replaced by the latest one
+	if interval := <-helper.intervals; interval != 30*time.Second {
+		t.Errorf("expected a 30s refresh interval, got %s", interval)
+	}
+}
@@ -46,17 +46,21 @@ func (w *Webhook) Execute(domain, currentIP string) error {
 		method = http.MethodPost
 	}
 
+	// report the family of the pushed address, so that dual-stack
+	// setups can tell A and AAAA updates apart
+	ipType := utils.GetIPType(currentIP)
+
 	reqURL, reqBody := "", ""
 	var err error
 	// send HTTP get request
 	if method == http.MethodGet {
-		reqURL, err = w.buildReqURL(domain, currentIP, w.conf.IPType)
+		reqURL, err = w.buildReqURL(domain, currentIP, ipType)
 		if err != nil {
 			return err
 		}
 	} else {
 		reqURL = w.conf.Webhook.URL
-		reqBody, err = w.buildReqBody(domain, currentIP, w.conf.IPType)
+		reqBody, err = w.buildReqBody(domain, currentIP, ipType)
 		if err != nil {
 			return err
 		}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"text/template"
 
+	"github.com/TimothyYe/godns/internal/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,9 +18,11 @@ func buildTemplate(currentIP, domain string, tplsrc string) string {
 	data := struct {
 		CurrentIP string
 		Domain    string
+		IPType    string
 	}{
 		currentIP,
 		domain,
+		utils.GetIPType(currentIP),
 	}
 
 	var tpl bytes.Buffer
@@ -14,6 +14,9 @@ import (
 	"github.com/miekg/dns"
 )
 
+// ErrNotFound is returned by LookupHost when the host has no record of the requested type.
+var ErrNotFound = errors.New("no such record")
+
 // DNSResolver represents a dns resolver.
 type DNSResolver struct {
 	Servers    []string
@@ -83,6 +86,9 @@ func (r *DNSResolver) lookupHost(host string, dnsType uint16, triesLeft int) ([]
 		return result, err
 	}
 
+	if in != nil && in.Rcode == dns.RcodeNameError {
+		return result, fmt.Errorf("%w: %s", ErrNotFound, dns.RcodeToString[in.Rcode])
+	}
 	if in != nil && in.Rcode != dns.RcodeSuccess {
 		return result, errors.New(dns.RcodeToString[in.Rcode])
 	}
@@ -95,7 +101,7 @@ func (r *DNSResolver) lookupHost(host string, dnsType uint16, triesLeft int) ([]
 				}
 			}
 		} else {
-			return result, errors.New("empty result")
+			return result, fmt.Errorf("%w: empty result", ErrNotFound)
 		}
 	}
 
@@ -107,9 +113,47 @@ func (r *DNSResolver) lookupHost(host string, dnsType uint16, triesLeft int) ([]
 				}
 			}
 		} else {
-			return result, fmt.Errorf("cannot resolve domain %s, please make sure the IP type is right", host)
+			return result, fmt.Errorf("%w: cannot resolve domain %s, please make sure the IP type is right", ErrNotFound, host)
 		}
 	}
 
 	return result, err
 }
+
+// LookupZone returns the name of the zone holding the provided host, read from
+// the SOA record returned for it: in the answer section for the apex of a zone,
+// in the authority section otherwise.
+// In case of timeout retries query RetryTimes times.
+func (r *DNSResolver) LookupZone(host string) (string, error) {
+	return r.lookupZone(host, r.RetryTimes)
+}
+
+func (r *DNSResolver) lookupZone(host string, triesLeft int) (string, error) {
+	m1 := new(dns.Msg)
+	m1.Id = dns.Id()
+	m1.RecursionDesired = true
+	m1.Question = []dns.Question{{Name: dns.Fqdn(host), Qtype: dns.TypeSOA, Qclass: dns.ClassINET}}
+
+	c := &dns.Client{Timeout: 5 * time.Second}
+	in, _, err := c.Exchange(m1, r.Servers[r.r.Intn(len(r.Servers))])
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "i/o timeout") && triesLeft > 0 {
+			triesLeft--
+			return r.lookupZone(host, triesLeft)
+		}
+		return "", err
+	}
+
+	// a missing host is still in a zone, whose SOA record comes with the error
+	if in.Rcode != dns.RcodeSuccess && in.Rcode != dns.RcodeNameError {
+		return "", errors.New(dns.RcodeToString[in.Rcode])
+	}
+
+	for _, record := range append(in.Answer, in.Ns...) {
+		if soa, ok := record.(*dns.SOA); ok {
+			return strings.TrimSuffix(soa.Hdr.Name, "."), nil
+		}
+	}
+
+	return "", fmt.Errorf("no SOA record found for %s", host)
+}
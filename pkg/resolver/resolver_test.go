@@ -1,7 +1,10 @@
 package resolver
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"net"
 	"reflect"
 	"testing"
 
@@ -44,3 +47,107 @@ func TestLookupHostIPv6_ValidServer(t *testing.T) {
 		t.Error("result should be: 2001:4860:4860::8888")
 	}
 }
+
+func TestLookupZone(t *testing.T) {
+	// a local server holding the example.com and home.example.com zones
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		name := req.Question[0].Name
+		zone := "example.com."
+		if dns.IsSubDomain("home.example.com.", name) {
+			zone = "home.example.com."
+		}
+
+		soa, _ := dns.NewRR(zone + " 300 IN SOA ns1." + zone + " admin." + zone + " 1 7200 3600 1209600 300")
+		switch name {
+		case zone:
+			m.Answer = append(m.Answer, soa)
+		case "missing.example.com.":
+			m.Rcode = dns.RcodeNameError
+			m.Ns = append(m.Ns, soa)
+		default:
+			m.Ns = append(m.Ns, soa)
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen on UDP: ", err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: handler}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	defer func() {
+		_ = server.Shutdown()
+	}()
+
+	resolver := &DNSResolver{Servers: []string{pc.LocalAddr().String()}, RetryTimes: 1, r: rand.New(rand.NewSource(1))}
+	tests := map[string]string{
+		"example.com":          "example.com",
+		"www.example.com":      "example.com",
+		"missing.example.com":  "example.com",
+		"home.example.com":     "home.example.com",
+		"vpn.home.example.com": "home.example.com",
+	}
+	for host, want := range tests {
+		zone, err := resolver.LookupZone(host)
+		if err != nil || zone != want {
+			t.Errorf("LookupZone(%s): expected %s, got %s (%v)", host, want, zone, err)
+		}
+	}
+}
+
+func TestLookupHost_NotFound(t *testing.T) {
+	// a local server where www.example.com has only an A record
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		q := req.Question[0]
+		switch {
+		case q.Name == "www.example.com." && q.Qtype == dns.TypeA:
+			rr, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+			m.Answer = append(m.Answer, rr)
+		case q.Name == "www.example.com.":
+		case q.Name == "broken.example.com.":
+			m.Rcode = dns.RcodeServerFailure
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("cannot listen on UDP: ", err)
+	}
+	server := &dns.Server{PacketConn: pc, Handler: handler}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	defer func() {
+		_ = server.Shutdown()
+	}()
+
+	resolver := &DNSResolver{Servers: []string{pc.LocalAddr().String()}, RetryTimes: 1, r: rand.New(rand.NewSource(1))}
+	tests := []struct {
+		host     string
+		dnsType  uint16
+		notFound bool
+	}{
+		{"www.example.com", dns.TypeA, false},
+		{"www.example.com", dns.TypeAAAA, true},
+		{"missing.example.com", dns.TypeA, true},
+		{"broken.example.com", dns.TypeA, false},
+	}
+	for _, tt := range tests {
+		_, err := resolver.LookupHost(tt.host, tt.dnsType)
+		if errors.Is(err, ErrNotFound) != tt.notFound {
+			t.Errorf("LookupHost(%s, %s): expected not found to be %v, got %v", tt.host, dns.TypeToString[tt.dnsType], tt.notFound, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ export interface Domain {
 	domain_name: string;
 	sub_domains: string[];
 	provider?: string;
+	hostnames?: string[];
 }
 
 export async function get_domains(credentials: string): Promise<Domain[]> {
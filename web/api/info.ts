@@ -8,10 +8,12 @@ export interface Info {
 	sub_domain_num: number;
 	domains: Domain[];
 	public_ip: string;
+	public_ipv6?: string;
 	ip_mode: string;
 	provider: string;
 	is_multi_provider: boolean;
 	providers: string[];
+	next_runs?: { [domainKey: string]: number };
 }
 
 export async function get_info(credentials: string): Promise<Info> {
@@ -1,5 +1,19 @@
 import { get_api_server } from "./env";
 
+export interface ProviderCapabilities {
+	ipv6: boolean;
+	ttl: boolean;
+	proxied: boolean;
+	comment: boolean;
+	ownership: boolean;
+	zone: boolean;
+	create: boolean;
+	delete: boolean;
+	multiple_values: boolean;
+	txt: boolean;
+	wildcard: boolean;
+}
+
 export interface ProviderSetting {
 	name: string;
 	username: boolean;
@@ -9,6 +23,7 @@ export interface ProviderSetting {
 	app_key: boolean;
 	app_secret: boolean;
 	consumer_key: boolean;
+	capabilities: ProviderCapabilities;
 }
 
 export interface Provider {
@@ -24,6 +39,7 @@ export interface Provider {
 
 export interface MultiProviderConfig {
 	[providerName: string]: {
+		type?: string;
 		email?: string;
 		password?: string;
 		login_token?: string;
@@ -9,6 +9,7 @@ import { toast } from "react-toastify";
 import { PlusIcon, TrashIcon } from "@/components/icons";
 
 interface ProviderConfigForm {
+	name: string;
 	email: string;
 	password: string;
 	loginToken: string;
@@ -23,6 +24,7 @@ export const MultiProviderControl = () => {
 	const [providerSettings, setProviderSettings] = useState<ProviderSetting[]>([]);
 	const [selectedProvider, setSelectedProvider] = useState<string>('');
 	const [formData, setFormData] = useState<ProviderConfigForm>({
+		name: '',
 		email: '',
 		password: '',
 		loginToken: '',
@@ -39,29 +41,30 @@ export const MultiProviderControl = () => {
 
 	const availableProviders = useMemo(() => {
 		if (providerSettings) {
+			// a provider type can be added several times under different names
 			return providerSettings
-				.filter(setting => !providers[setting.name])
 				.map((setting) => ({
 					value: setting.name,
 					label: setting.name
 				}));
 		}
 		return [];
-	}, [providerSettings, providers]);
+	}, [providerSettings]);
 
 	const currentProviderSettings = useMemo(() => {
-		const providerName = editingProvider || selectedProvider;
-		if (providerName) {
-			const settings = providerSettings.filter((setting) => setting.name === providerName);
+		const providerType = editingProvider ? (providers[editingProvider]?.type || editingProvider) : selectedProvider;
+		if (providerType) {
+			const settings = providerSettings.filter((setting) => setting.name === providerType);
 			if (settings.length > 0) {
 				return settings[0];
 			}
 		}
 		return null;
-	}, [editingProvider, selectedProvider, providerSettings]);
+	}, [editingProvider, selectedProvider, providerSettings, providers]);
 
 	const resetForm = () => {
 		setFormData({
+			name: '',
 			email: '',
 			password: '',
 			loginToken: '',
@@ -108,6 +111,7 @@ export const MultiProviderControl = () => {
 		const config = providers[providerName];
 		if (config) {
 			setFormData({
+				name: providerName,
 				email: config.email || '',
 				password: config.password || '',
 				loginToken: config.login_token || '',
@@ -168,10 +172,17 @@ export const MultiProviderControl = () => {
 	const handleSaveProvider = async () => {
 		if (!validateForm()) return;
 
-		const providerName = editingProvider || selectedProvider;
+		const providerName = editingProvider || formData.name.trim() || selectedProvider;
 		if (!providerName) return;
 
+		if (!editingProvider && providers[providerName]) {
+			toast.error(`A provider named ${providerName} already exists`);
+			return;
+		}
+
 		const config: ProviderConfig = {};
+		const providerType = editingProvider ? providers[editingProvider]?.type : selectedProvider;
+		if (providerType && providerType !== providerName) config.type = providerType;
 		if (currentProviderSettings?.email) config.email = formData.email;
 		if (currentProviderSettings?.password) config.password = formData.password;
 		if (currentProviderSettings?.login_token) config.login_token = formData.loginToken;
@@ -262,6 +273,10 @@ export const MultiProviderControl = () => {
 
 			{currentProviderSettings && (
 				<>
+					{!editingProvider && (
+						renderTextField('Name', formData.name, (name) => setFormData(prev => ({ ...prev, name })), selectedProvider, 'text', 'Optional, to configure several accounts of the same provider, e.g. cf-work')
+					)}
+
 					{currentProviderSettings.email && (
 						renderTextField('Email', formData.email, (email) => setFormData(prev => ({ ...prev, email })), 'name@example.com')
 					)}
@@ -344,6 +359,9 @@ export const MultiProviderControl = () => {
 								<div className="flex items-start justify-between gap-4">
 									<h2 className="text-xl font-semibold tracking-tight theme-heading">
 										{providerName}
+										{providers[providerName]?.type && providers[providerName]?.type !== providerName ? (
+											<span className="ml-2 text-sm font-normal theme-muted">{providers[providerName]?.type}</span>
+										) : null}
 									</h2>
 									<div className="theme-chip-violet rounded-full px-3 py-1 text-xs font-medium">
 										{Object.keys(providers[providerName] || {}).length} fields